@@ -0,0 +1,177 @@
+package vino
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// BatchConfig tunes StreamBatch and ChanBatch. A batch is flushed as soon
+// as any of the enabled limits is hit; a zero limit is disabled.
+//
+// Fields:
+//   - MaxSize: The maximum number of elements in a batch.
+//   - MaxWeight: The maximum total weight of a batch, as measured by
+//     Weigh. An element that would push a batch over the limit goes to the
+//     next batch instead, and an element heavier than the limit on its own
+//     makes up a batch by itself.
+//   - Weigh: Measures an element, typically its size in bytes. Required
+//     when MaxWeight is set.
+//   - Linger: How long a batch may wait for more elements after its first
+//     one arrived.
+//   - Clock: The clock Linger is measured with. Defaults to SystemClock.
+type BatchConfig[T any] struct {
+	MaxSize   int
+	MaxWeight int
+	Weigh     func(T) int
+	Linger    time.Duration
+	Clock     Clock
+}
+
+// batcher is the state machine shared by StreamBatch and ChanBatch.
+type batcher[T any] struct {
+	config BatchConfig[T]
+	batch  []T
+	weight int
+	first  time.Time
+}
+
+// add puts x into the current batch and returns the batches that are full
+// as a result. The batch x would overflow is flushed before x is added.
+func (b *batcher[T]) add(now time.Time, x T) [][]T {
+	var ret [][]T
+	if at, ok := b.deadline(); ok && !at.After(now) {
+		ret = append(ret, b.flush())
+	}
+
+	w := 0
+	if b.config.MaxWeight > 0 {
+		w = b.config.Weigh(x)
+		if len(b.batch) > 0 && b.weight+w > b.config.MaxWeight {
+			ret = append(ret, b.flush())
+		}
+	}
+	if len(b.batch) == 0 {
+		b.first = now
+	}
+	b.batch = append(b.batch, x)
+	b.weight += w
+
+	if b.config.MaxSize > 0 && len(b.batch) >= b.config.MaxSize ||
+		b.config.MaxWeight > 0 && b.weight >= b.config.MaxWeight {
+		ret = append(ret, b.flush())
+	}
+	return ret
+}
+
+// deadline reports when the current batch has lingered long enough.
+func (b *batcher[T]) deadline() (time.Time, bool) {
+	if b.config.Linger <= 0 || len(b.batch) == 0 {
+		return time.Time{}, false
+	}
+	return b.first.Add(b.config.Linger), true
+}
+
+// flush hands out the current batch, which may be empty, and starts a new
+// one.
+func (b *batcher[T]) flush() []T {
+	batch := b.batch
+	b.batch, b.weight = nil, 0
+	return batch
+}
+
+type batchStream[T any] struct {
+	s     Stream[T]
+	b     *batcher[T]
+	ready [][]T
+	err   error
+}
+
+func (s *batchStream[T]) Next() ([]T, error) {
+	for len(s.ready) == 0 {
+		if s.err != nil {
+			return nil, s.err
+		}
+		x, err := s.s.Next()
+		if errors.Is(err, ErrStreamExhausted) {
+			s.err = err
+			if batch := s.b.flush(); len(batch) > 0 {
+				s.ready = append(s.ready, batch)
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		s.ready = s.b.add(s.b.config.Clock.Now(), x)
+	}
+	batch := s.ready[0]
+	s.ready = s.ready[1:]
+	return batch, nil
+}
+
+func (s *batchStream[T]) Close() error {
+	return StreamClose(s.s)
+}
+
+// StreamBatch returns a stream that groups the elements of s into batches
+// following config. Once s is exhausted, the last partial batch is
+// flushed rather than dropped. Since a stream is pulled on demand, a
+// batch that has lingered too long is only flushed when the next element
+// arrives; use ChanBatch to have batches flushed on time.
+func StreamBatch[T any](s Stream[T], config BatchConfig[T]) Stream[[]T] {
+	config.Clock = clockOrSystem(config.Clock)
+	return &batchStream[T]{s: s, b: &batcher[T]{config: config}}
+}
+
+// ChanBatch groups the elements received from ch into batches following
+// config and sends them on the returned channel. A batch is flushed on
+// time once it has lingered for config.Linger, even if ch stays silent.
+// When ch is closed, the last partial batch is flushed and the returned
+// channel is closed. When ctx is done, the returned channel is closed and
+// the pending batch is dropped.
+func ChanBatch[T any](ctx context.Context, ch <-chan T, config BatchConfig[T]) <-chan []T {
+	config.Clock = clockOrSystem(config.Clock)
+	out := make(chan []T)
+	go func() {
+		defer close(out)
+		b := &batcher[T]{config: config}
+		send := func(batches ...[]T) bool {
+			for _, batch := range batches {
+				if len(batch) == 0 {
+					continue
+				}
+				select {
+				case out <- batch:
+				case <-ctx.Done():
+					return false
+				}
+			}
+			return true
+		}
+
+		timer := &deadlineTimer{clock: config.Clock}
+		defer timer.release()
+		for {
+			wait := timer.reset(b.deadline())
+			select {
+			case x, ok := <-ch:
+				if !ok {
+					send(b.flush())
+					return
+				}
+				if !send(b.add(config.Clock.Now(), x)...) {
+					return
+				}
+			case <-wait:
+				timer.fired()
+				if !send(b.flush()) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,85 @@
+package vino_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/humbornjo/vino"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamBatch(t *testing.T) {
+	length := func(s string) int { return len(s) }
+
+	tests := []struct {
+		name   string
+		xs     []string
+		config BatchConfig[string]
+		want   [][]string
+	}{
+		{
+			"size",
+			[]string{"a", "b", "c", "d", "e"},
+			BatchConfig[string]{MaxSize: 2},
+			[][]string{{"a", "b"}, {"c", "d"}, {"e"}},
+		},
+		{
+			"weight",
+			[]string{"aa", "bb", "ccc", "dddddd", "e"},
+			BatchConfig[string]{MaxWeight: 5, Weigh: length},
+			[][]string{{"aa", "bb"}, {"ccc"}, {"dddddd"}, {"e"}},
+		},
+		{
+			"size or weight",
+			[]string{"a", "b", "c", "dddd", "e"},
+			BatchConfig[string]{MaxSize: 3, MaxWeight: 4, Weigh: length},
+			[][]string{{"a", "b", "c"}, {"dddd"}, {"e"}},
+		},
+		{
+			"nothing",
+			[]string{},
+			BatchConfig[string]{MaxSize: 3},
+			[][]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := StreamCollect(StreamBatch(streamOf(tt.xs...), tt.config))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	clock := NewManualClock(time.Unix(0, 0))
+	s := &tickingStream{clock: clock, xs: []int{1, 2, 3, 4}, gaps: []time.Duration{0, time.Second, 2 * time.Second, 0}}
+	got, err := StreamCollect(StreamBatch[int](s, BatchConfig[int]{MaxSize: 10, Linger: 2 * time.Second, Clock: clock}))
+	assert.NoError(t, err)
+	assert.Equal(t, [][]int{{1, 2}, {3, 4}}, got)
+}
+
+func TestChanBatch(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	ch := make(chan int)
+	out := ChanBatch(context.Background(), ch, BatchConfig[int]{MaxSize: 3, Linger: time.Second, Clock: clock})
+
+	ch <- 1
+	ch <- 2
+	ch <- 3
+	assert.Equal(t, []int{1, 2, 3}, <-out)
+
+	// The first element of a batch arms the linger timer, and the timers of
+	// the previous batch are gone by then.
+	ch <- 4
+	clock.BlockUntil(1)
+	assert.Equal(t, []time.Time{time.Unix(1, 0)}, clock.Deadlines())
+	clock.Advance(time.Second)
+	assert.Equal(t, []int{4}, <-out)
+
+	ch <- 5
+	close(ch)
+	assert.Equal(t, []int{5}, <-out)
+	_, ok := <-out
+	assert.False(t, ok)
+}
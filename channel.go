@@ -1,6 +1,7 @@
 package vino
 
 import (
+	"context"
 	"errors"
 	"sync"
 	"weak"
@@ -292,3 +293,76 @@ func (c *chanBroadcast[T]) Len() int {
 func (c *chanBroadcast[T]) Cap() int {
 	return cap(c.tunnel)
 }
+
+// ------------------------------------------------------------------------
+//  Stream <-> Channel Adapters
+// ------------------------------------------------------------------------
+
+// StreamToChan starts a goroutine that pulls every element of s and sends
+// it on the returned channel, which has the given buffer size. Once s is
+// exhausted, fails or ctx is done, the goroutine closes s and both
+// returned channels. A failure of s, or ctx.Err() on cancellation, is sent
+// on the error channel before it is closed, so receiving from it after
+// the element channel is closed tells how the stream ended: a nil error
+// means s was exhausted.
+//
+// The output can be fed to NewChanMut or NewChanBroadcast by forwarding it
+// to their In channel.
+func StreamToChan[T any](ctx context.Context, s Stream[T], buffer uint) (<-chan T, <-chan error) {
+	out := make(chan T, buffer)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(errs)
+		defer close(out)
+		defer StreamClose(s)
+		for {
+			if err := ctx.Err(); err != nil {
+				errs <- err
+				return
+			}
+			x, err := s.Next()
+			if errors.Is(err, ErrStreamExhausted) {
+				return
+			}
+			if err != nil {
+				errs <- err
+				return
+			}
+			select {
+			case out <- x:
+			case <-ctx.Done():
+				errs <- ctx.Err()
+				return
+			}
+		}
+	}()
+	return out, errs
+}
+
+type chanStream[T any] struct {
+	ctx context.Context
+	ch  <-chan T
+}
+
+func (s *chanStream[T]) Next() (T, error) {
+	if err := s.ctx.Err(); err != nil {
+		return *new(T), err
+	}
+	select {
+	case x, ok := <-s.ch:
+		if !ok {
+			return *new(T), ErrStreamExhausted
+		}
+		return x, nil
+	case <-s.ctx.Done():
+		return *new(T), s.ctx.Err()
+	}
+}
+
+// ChanToStream returns a stream over the elements received from ch, such
+// as the Out channel of a resizable or broadcast channel. The stream is
+// exhausted once ch is closed, and fails with ctx.Err() once ctx is done.
+// Next blocks until an element is received.
+func ChanToStream[T any](ctx context.Context, ch <-chan T) Stream[T] {
+	return &chanStream[T]{ctx: ctx, ch: ch}
+}
@@ -1,11 +1,14 @@
 package vino_test
 
 import (
+	"context"
+	"errors"
 	"sync"
 	"testing"
 	"time"
 
 	. "github.com/humbornjo/vino"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestChanMut(t *testing.T) {
@@ -157,3 +160,49 @@ func TestChanBroadcast_MultiGuest(t *testing.T) {
 	wg.Wait()
 	cb.Close()
 }
+
+func TestStreamToChan(t *testing.T) {
+	out, errs := StreamToChan(context.Background(), streamOf(1, 2, 3), 1)
+	got := []int{}
+	for x := range out {
+		got = append(got, x)
+	}
+	assert.Equal(t, []int{1, 2, 3}, got)
+	assert.NoError(t, <-errs)
+
+	errBoom := errors.New("boom")
+	out, errs = StreamToChan[int](context.Background(), &failingStream{n: 1, err: errBoom}, 0)
+	assert.Equal(t, 0, <-out)
+	_, ok := <-out
+	assert.False(t, ok)
+	assert.ErrorIs(t, <-errs, errBoom)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	src := &failingStream{n: 100, err: ErrStreamExhausted}
+	out, errs = StreamToChan[int](ctx, src, 0)
+	<-out
+	cancel()
+	for range out {
+	}
+	assert.ErrorIs(t, <-errs, context.Canceled)
+	assert.True(t, src.closed)
+}
+
+func TestChanToStream(t *testing.T) {
+	ch := NewChanMut[int](4)
+	go func() {
+		for i := 0; i < 3; i++ {
+			ch.In() <- i
+		}
+		ch.Close()
+	}()
+	got, err := StreamCollect(ChanToStream(context.Background(), ch.Out()))
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 1, 2}, got)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := ChanToStream(ctx, make(chan int))
+	cancel()
+	_, err = s.Next()
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,281 @@
+package vino
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Checkpointer is implemented by the streams whose position can be saved
+// and restored, which lets a long job resume where it stopped instead of
+// starting over. A checkpoint taken from a stream can be restored into a
+// stream of the same kind built over the same input, typically in a later
+// run of the same program.
+//
+// The sources provided by this package implement it: the streams returned
+// by NewRepeatedStream, SliceToStream, NewLineStream, NewCSVStream,
+// NewJSONLStream and NewLengthPrefixedStream. The reader backed ones seek
+// to the saved offset if their reader implements io.Seeker, and otherwise
+// skip forward, which requires the reader to be at its beginning.
+type Checkpointer interface {
+	Checkpoint() ([]byte, error)
+	Restore(checkpoint []byte) error
+}
+
+// Every checkpoint starts with a byte identifying the kind of stream it
+// was taken from, followed by varint encoded fields.
+const (
+	checkpointRepeated       byte = 'R'
+	checkpointLine           byte = 'L'
+	checkpointJSONL          byte = 'J'
+	checkpointCSV            byte = 'C'
+	checkpointLengthPrefixed byte = 'P'
+)
+
+func encodeCheckpoint(kind byte, fields ...int64) []byte {
+	buf := []byte{kind}
+	for _, f := range fields {
+		buf = binary.AppendVarint(buf, f)
+	}
+	return buf
+}
+
+func decodeCheckpoint(kind byte, checkpoint []byte, n int) ([]int64, error) {
+	if len(checkpoint) == 0 || checkpoint[0] != kind {
+		return nil, errors.New("checkpoint kind mismatch")
+	}
+	buf := checkpoint[1:]
+	fields := make([]int64, n)
+	for i := range fields {
+		f, size := binary.Varint(buf)
+		if size <= 0 {
+			return nil, errors.New("checkpoint is malformed")
+		}
+		fields[i], buf = f, buf[size:]
+	}
+	if len(buf) > 0 {
+		return nil, errors.New("checkpoint is malformed")
+	}
+	return fields, nil
+}
+
+// seekReader moves r to offset, either by seeking or by discarding the
+// bytes in between when r is not an io.Seeker.
+func seekReader(r io.Reader, offset int64) error {
+	if seeker, ok := r.(io.Seeker); ok {
+		_, err := seeker.Seek(offset, io.SeekStart)
+		return err
+	}
+	if _, err := io.CopyN(io.Discard, r, offset); err != nil {
+		return fmt.Errorf("skip to offset %d: %w", offset, err)
+	}
+	return nil
+}
+
+func (s *repeatedStream[T]) Checkpoint() ([]byte, error) {
+	return encodeCheckpoint(checkpointRepeated, int64(s.idx), int64(s.repeat)), nil
+}
+
+func (s *repeatedStream[T]) Restore(checkpoint []byte) error {
+	fields, err := decodeCheckpoint(checkpointRepeated, checkpoint, 2)
+	if err != nil {
+		return err
+	}
+	if fields[0] < 0 || fields[0] > int64(len(s.xs)) {
+		return errors.New("checkpoint is out of range")
+	}
+	s.idx, s.repeat = int(fields[0]), int(fields[1])
+	return nil
+}
+
+func (s *lineStream) Checkpoint() ([]byte, error) {
+	return encodeCheckpoint(checkpointLine, s.offset, int64(s.line)), nil
+}
+
+func (s *lineStream) Restore(checkpoint []byte) error {
+	fields, err := decodeCheckpoint(checkpointLine, checkpoint, 2)
+	if err != nil {
+		return err
+	}
+	return s.restore(fields[0], int(fields[1]))
+}
+
+// restore moves the stream to offset, where the given line starts.
+func (s *lineStream) restore(offset int64, line int) error {
+	if err := seekReader(s.r, offset); err != nil {
+		return err
+	}
+	s.br.Reset(s.r)
+	s.offset, s.line, s.err = offset, line, nil
+	return nil
+}
+
+func (s *jsonlStream[T]) Checkpoint() ([]byte, error) {
+	return encodeCheckpoint(checkpointJSONL, s.offset, int64(s.line), int64(s.record)), nil
+}
+
+func (s *jsonlStream[T]) Restore(checkpoint []byte) error {
+	fields, err := decodeCheckpoint(checkpointJSONL, checkpoint, 3)
+	if err != nil {
+		return err
+	}
+	if err := s.restore(fields[0], int(fields[1])); err != nil {
+		return err
+	}
+	s.record = int(fields[2])
+	return nil
+}
+
+func (s *csvStream) Checkpoint() ([]byte, error) {
+	return encodeCheckpoint(checkpointCSV, s.base+s.cr.InputOffset(), int64(s.record)), nil
+}
+
+func (s *csvStream) Restore(checkpoint []byte) error {
+	fields, err := decodeCheckpoint(checkpointCSV, checkpoint, 2)
+	if err != nil {
+		return err
+	}
+	if err := seekReader(s.r, fields[0]); err != nil {
+		return err
+	}
+	s.reset(s.r)
+	s.base, s.record, s.err = fields[0], int(fields[1]), nil
+	return nil
+}
+
+func (s *lengthPrefixedStream) Checkpoint() ([]byte, error) {
+	return encodeCheckpoint(checkpointLengthPrefixed, s.offset, int64(s.record)), nil
+}
+
+func (s *lengthPrefixedStream) Restore(checkpoint []byte) error {
+	fields, err := decodeCheckpoint(checkpointLengthPrefixed, checkpoint, 2)
+	if err != nil {
+		return err
+	}
+	if err := seekReader(s.r, fields[0]); err != nil {
+		return err
+	}
+	s.br.Reset(s.r)
+	s.offset, s.record, s.err = fields[0], int(fields[1]), nil
+	return nil
+}
+
+// ------------------------------------------------------------------------
+//  Checkpoint Files
+// ------------------------------------------------------------------------
+
+// CheckpointConfig tunes StreamCheckpointFile.
+//
+// Fields:
+//   - Path: The file the checkpoints are persisted to.
+//   - Every: Save a checkpoint every so many elements. Zero disables it.
+//   - Interval: Save a checkpoint once so much time has passed since the
+//     last one. Zero disables it.
+//   - Clock: The clock Interval is measured with. Defaults to SystemClock.
+type CheckpointConfig struct {
+	Path     string
+	Every    int
+	Interval time.Duration
+	Clock    Clock
+}
+
+type checkpointFileStream[T any] struct {
+	s      Stream[T]
+	cp     Checkpointer
+	config CheckpointConfig
+	count  int
+	last   time.Time
+}
+
+func (s *checkpointFileStream[T]) Next() (T, error) {
+	due := s.config.Every > 0 && s.count >= s.config.Every
+	if s.config.Interval > 0 && s.config.Clock.Now().Sub(s.last) >= s.config.Interval {
+		due = true
+	}
+	if due {
+		if err := s.save(); err != nil {
+			return *new(T), err
+		}
+	}
+
+	x, err := s.s.Next()
+	if errors.Is(err, ErrStreamExhausted) {
+		if err := s.save(); err != nil {
+			return *new(T), err
+		}
+		return *new(T), ErrStreamExhausted
+	}
+	if err != nil {
+		return *new(T), err
+	}
+	s.count++
+	return x, nil
+}
+
+// save atomically replaces the checkpoint file with the current position
+// of the stream.
+func (s *checkpointFileStream[T]) save() error {
+	checkpoint, err := s.cp.Checkpoint()
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.config.Path), filepath.Base(s.config.Path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	w := bufio.NewWriter(tmp)
+	if _, err := w.Write(checkpoint); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := errors.Join(w.Flush(), tmp.Sync(), tmp.Close()); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.config.Path); err != nil {
+		return err
+	}
+	s.count = 0
+	s.last = s.config.Clock.Now()
+	return nil
+}
+
+// Close saves a last checkpoint and closes the wrapped stream.
+func (s *checkpointFileStream[T]) Close() error {
+	return errors.Join(s.save(), StreamClose(s.s))
+}
+
+// StreamCheckpointFile wraps s so that its position is persisted to the
+// file at config.Path as the stream is consumed. If the file already
+// exists, s is first restored from it, resuming a previous run.
+//
+// A checkpoint is saved before pulling an element once config.Every
+// elements have been yielded or config.Interval has elapsed since the
+// last checkpoint, as well as when s is exhausted and when the stream is
+// closed. A checkpoint covers the elements yielded before the call to
+// Next that saves it, so a crash may replay the element that was being
+// handled at the time, but never skips one. s must implement Checkpointer.
+func StreamCheckpointFile[T any](s Stream[T], config CheckpointConfig) (Stream[T], error) {
+	cp, ok := s.(Checkpointer)
+	if !ok {
+		return nil, errors.New("stream is not checkpointable")
+	}
+	config.Clock = clockOrSystem(config.Clock)
+
+	checkpoint, err := os.ReadFile(config.Path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, err
+	default:
+		if err := cp.Restore(checkpoint); err != nil {
+			return nil, fmt.Errorf("restore %s: %w", config.Path, err)
+		}
+	}
+	return &checkpointFileStream[T]{s: s, cp: cp, config: config, last: config.Clock.Now()}, nil
+}
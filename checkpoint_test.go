@@ -0,0 +1,155 @@
+package vino_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/humbornjo/vino"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckpointer(t *testing.T) {
+	input := "a,1\nb,2\nc,3\n"
+	asString := func(b []byte) string { return string(b) }
+	joined := func(r []string) string { return strings.Join(r, ",") }
+
+	// Every case builds a checkpointable source along with a view of it as
+	// a stream of strings.
+	tests := []struct {
+		name string
+		new  func() (Checkpointer, Stream[string])
+		want []string
+	}{
+		{
+			"repeated",
+			func() (Checkpointer, Stream[string]) {
+				s := NewRepeatedStream([]string{"a", "b"}, 1)
+				return s.(Checkpointer), s
+			},
+			[]string{"a", "b", "a", "b"},
+		},
+		{
+			"lines",
+			func() (Checkpointer, Stream[string]) {
+				s := NewLineStream(strings.NewReader(input))
+				return s.(Checkpointer), s
+			},
+			[]string{"a,1", "b,2", "c,3"},
+		},
+		{
+			"csv",
+			func() (Checkpointer, Stream[string]) {
+				s := NewCSVStream(strings.NewReader(input))
+				return s.(Checkpointer), StreamMap(s, joined)
+			},
+			[]string{"a,1", "b,2", "c,3"},
+		},
+		{
+			"jsonl",
+			func() (Checkpointer, Stream[string]) {
+				s := NewJSONLStream[string](strings.NewReader("\"a\"\n\"b\"\n\"c\"\n"))
+				return s.(Checkpointer), s
+			},
+			[]string{"a", "b", "c"},
+		},
+		{
+			"length prefixed",
+			func() (Checkpointer, Stream[string]) {
+				buf := &bytes.Buffer{}
+				StreamWriteLengthPrefixed(buf, streamOf([]byte("a"), []byte("b"), []byte("c")))
+				s := NewLengthPrefixedStream(bytes.NewReader(buf.Bytes()), 0)
+				return s.(Checkpointer), StreamMap(s, asString)
+			},
+			[]string{"a", "b", "c"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for split := 0; split <= len(tt.want); split++ {
+				src, srcStream := tt.new()
+				dst, dstStream := tt.new()
+				for range split {
+					_, err := srcStream.Next()
+					assert.NoError(t, err)
+				}
+				checkpoint, err := src.Checkpoint()
+				assert.NoError(t, err)
+				assert.NoError(t, dst.Restore(checkpoint))
+				rest, err := StreamCollect(dstStream)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want[split:], rest)
+			}
+		})
+	}
+
+	err := NewRepeatedStream([]int{1}, 0).(Checkpointer).Restore([]byte("L\x00\x00"))
+	assert.EqualError(t, err, "checkpoint kind mismatch")
+}
+
+func TestCheckpointer_JSONLRecord(t *testing.T) {
+	input := "1\n\n2\n3\noops\n"
+	src := NewJSONLStream[int](strings.NewReader(input))
+	for range 2 {
+		_, err := src.Next()
+		assert.NoError(t, err)
+	}
+	checkpoint, err := src.(Checkpointer).Checkpoint()
+	assert.NoError(t, err)
+
+	// Record numbers carry over, blank lines aside.
+	dst := NewJSONLStream[int](strings.NewReader(input))
+	assert.NoError(t, dst.(Checkpointer).Restore(checkpoint))
+	x, err := dst.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, x)
+	_, err = dst.Next()
+	var rerr *RecordError
+	if assert.ErrorAs(t, err, &rerr) {
+		assert.Equal(t, 4, rerr.Record)
+		assert.Equal(t, 5, rerr.Line)
+	}
+
+	// Line checkpoints do not carry the record number.
+	line, err := NewLineStream(strings.NewReader(input)).(Checkpointer).Checkpoint()
+	assert.NoError(t, err)
+	assert.EqualError(t, dst.(Checkpointer).Restore(line), "checkpoint kind mismatch")
+}
+
+func TestStreamCheckpointFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "job.checkpoint")
+	input := "1\n2\n3\n4\n5\n"
+	config := CheckpointConfig{Path: path, Every: 2}
+
+	s, err := StreamCheckpointFile(NewLineStream(strings.NewReader(input)), config)
+	assert.NoError(t, err)
+	for _, want := range []string{"1", "2", "3"} {
+		x, err := s.Next()
+		assert.NoError(t, err)
+		assert.Equal(t, want, x)
+	}
+	// The job crashes here: the last checkpoint was saved before "3".
+
+	s, err = StreamCheckpointFile(NewLineStream(strings.NewReader(input)), config)
+	assert.NoError(t, err)
+	rest, err := StreamCollect(s)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"3", "4", "5"}, rest)
+
+	// The job is done, a rerun has nothing left to do.
+	s, err = StreamCheckpointFile(NewLineStream(strings.NewReader(input)), config)
+	assert.NoError(t, err)
+	rest, err = StreamCollect(s)
+	assert.NoError(t, err)
+	assert.Empty(t, rest)
+
+	assert.NoError(t, os.WriteFile(path, []byte("garbage"), 0o644))
+	_, err = StreamCheckpointFile(NewLineStream(strings.NewReader(input)), config)
+	assert.Error(t, err)
+
+	_, err = StreamCheckpointFile(streamOf(1), config)
+	assert.Error(t, err)
+}
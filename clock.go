@@ -0,0 +1,200 @@
+package vino
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// Clock abstracts the passing of wall-clock time so that time-driven
+// stages can be tested deterministically.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (systemClock) afterStop(d time.Duration) (<-chan time.Time, func()) {
+	t := time.NewTimer(d)
+	return t.C, func() { t.Stop() }
+}
+
+// SystemClock is the Clock backed by the time package. It is used
+// whenever a nil Clock is given to a stage.
+var SystemClock Clock = systemClock{}
+
+func clockOrSystem(c Clock) Clock {
+	if c == nil {
+		return SystemClock
+	}
+	return c
+}
+
+// stoppableClock is implemented by the clocks of this package, whose After
+// channels can be released before they fire.
+type stoppableClock interface {
+	afterStop(d time.Duration) (<-chan time.Time, func())
+}
+
+// clockAfter is like c.After, but also returns a function that releases
+// the channel if it has not fired yet. For clocks that do not support it,
+// the function does nothing and the channel is left to fire.
+func clockAfter(c Clock, d time.Duration) (<-chan time.Time, func()) {
+	if sc, ok := c.(stoppableClock); ok {
+		return sc.afterStop(d)
+	}
+	return c.After(d), func() {}
+}
+
+// deadlineTimer keeps a single timer armed on the deadline of a windower or
+// batcher, only re-arming it when the deadline moves, so that loops that
+// check the deadline on every iteration do not pile up timers.
+type deadlineTimer struct {
+	clock Clock
+	at    time.Time
+	c     <-chan time.Time
+	stop  func()
+}
+
+// reset arms the timer on at, or disarms it if ok is false, and returns
+// the channel to wait on. A nil channel is returned when disarmed, which
+// blocks forever in a select.
+func (t *deadlineTimer) reset(at time.Time, ok bool) <-chan time.Time {
+	if t.c != nil && ok && at.Equal(t.at) {
+		return t.c
+	}
+	t.release()
+	if ok {
+		t.at = at
+		t.c, t.stop = clockAfter(t.clock, at.Sub(t.clock.Now()))
+	}
+	return t.c
+}
+
+// fired tells the timer that its channel was received from.
+func (t *deadlineTimer) fired() {
+	t.c = nil
+}
+
+func (t *deadlineTimer) release() {
+	if t.c != nil {
+		t.stop()
+		t.c = nil
+	}
+}
+
+// ManualClock is a Clock whose time only moves when told to. Channels
+// returned by After fire once Advance or Set moves the time past their
+// deadline, and right away if the deadline has already passed.
+type ManualClock struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	waiters []manualWaiter
+}
+
+type manualWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+// NewManualClock creates a ManualClock that starts at now.
+func NewManualClock(now time.Time) *ManualClock {
+	c := &ManualClock{now: now}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+// Now returns the current time of the clock.
+func (c *ManualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// After returns a channel that receives the time of the clock once it has
+// moved d past the current time.
+func (c *ManualClock) After(d time.Duration) <-chan time.Time {
+	ch, _ := c.afterStop(d)
+	return ch
+}
+
+func (c *ManualClock) afterStop(d time.Duration) (<-chan time.Time, func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch, func() {}
+	}
+	c.waiters = append(c.waiters, manualWaiter{at: c.now.Add(d), ch: ch})
+	c.cond.Broadcast()
+	return ch, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		for i, w := range c.waiters {
+			if w.ch == ch {
+				c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// BlockUntil blocks until at least n channels returned by After are
+// waiting for the clock to move. It lets tests make sure a goroutine has
+// armed its timer before advancing the clock. The stages of this package
+// release their timers once they stop waiting on them, so these do not
+// count.
+func (c *ManualClock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.waiters) < n {
+		c.cond.Wait()
+	}
+}
+
+// Deadlines returns the times at which the channels returned by After
+// that are still waiting will fire, earliest first.
+func (c *ManualClock) Deadlines() []time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ret := make([]time.Time, len(c.waiters))
+	for i, w := range c.waiters {
+		ret[i] = w.at
+	}
+	slices.SortFunc(ret, time.Time.Compare)
+	return ret
+}
+
+// Advance moves the clock forward by d.
+func (c *ManualClock) Advance(d time.Duration) {
+	c.Set(c.Now().Add(d))
+}
+
+// Set moves the clock to now and fires every After channel whose deadline
+// has been reached. Moving the clock backwards is allowed but never fires
+// anything.
+func (c *ManualClock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+	waiters := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(now) {
+			waiters = append(waiters, w)
+			continue
+		}
+		w.ch <- now
+	}
+	c.waiters = waiters
+}
@@ -0,0 +1,130 @@
+// Command genmap emits the type-safe Map and StreamZipWith families of
+// vino up to a given arity. It is run by go generate from the root of the
+// module:
+//
+//	go run ./cmd/genmap -max 5 -o map_gen.go
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"strings"
+	"text/template"
+)
+
+var tmpl = template.Must(template.New("map").Funcs(template.FuncMap{
+	// join repeats format for the arguments 1 to n, replacing # with the
+	// position of the argument.
+	"join": func(n int, format string, sep string) string {
+		return join(1, n, format, sep)
+	},
+	// joinRest is join for the arguments 2 to n.
+	"joinRest": func(n int, format string, sep string) string {
+		return join(2, n, format, sep)
+	},
+	"zipName": func(n int) string {
+		if n == 2 {
+			return "StreamZipWith"
+		}
+		return fmt.Sprint("StreamZipWith", n)
+	},
+}).Parse(`// Code generated by genmap; DO NOT EDIT.
+
+package vino
+
+import "errors"
+{{range .Maps}}
+{{- if eq . 1}}
+// Map is the type-safe counterpart of FunctionalMap for a single slice: it
+// returns the results of fn applied to every element of xs1.
+func Map[T1 any, U any](fn func(T1) U, xs1 []T1) []U {
+	ret := make([]U, len(xs1))
+	for i := range xs1 {
+		ret[i] = fn(xs1[i])
+	}
+	return ret
+}
+{{else}}
+// Map{{.}} is the type-safe counterpart of FunctionalMap for {{.}} slices: it
+// returns the results of fn applied to the corresponding elements of
+// {{join . "xs#" ", "}}. It fails if the slices differ in length.
+func Map{{.}}[{{join . "T# any" ", "}}, U any](fn func({{join . "T#" ", "}}) U, {{join . "xs# []T#" ", "}}) ([]U, error) {
+	n := len(xs1)
+	if {{joinRest . "len(xs#) != n" " || "}} {
+		return nil, errors.New("input parameter slice length mismatch")
+	}
+	ret := make([]U, n)
+	for i := range n {
+		ret[i] = fn({{join . "xs#[i]" ", "}})
+	}
+	return ret, nil
+}
+{{end}}
+{{- end}}
+{{- range .Zips}}
+type zipWith{{.}}Stream[{{join . "T# any" ", "}}, U any] struct {
+	{{join . "s# Stream[T#]" "\n"}}
+	fn func({{join . "T#" ", "}}) U
+}
+
+func (s *zipWith{{.}}Stream[{{join . "T#" ", "}}, U]) Next() (U, error) {
+	{{join . "x#, err# := s.s#.Next()" "\n"}}
+	if err := zipWithError({{join . "err#" ", "}}); err != nil {
+		return *new(U), err
+	}
+	return s.fn({{join . "x#" ", "}}), nil
+}
+
+func (s *zipWith{{.}}Stream[{{join . "T#" ", "}}, U]) Close() error {
+	return errors.Join({{join . "StreamClose(s.s#)" ", "}})
+}
+
+// {{zipName .}} is the stream counterpart of Map{{.}}: it returns a stream of
+// the results of fn applied to the corresponding elements of
+// {{join . "s#" ", "}}. The streams are expected to end together, and the
+// resulting stream fails if they do not. Closing it closes all of them.
+func {{zipName .}}[{{join . "T# any" ", "}}, U any](fn func({{join . "T#" ", "}}) U, {{join . "s# Stream[T#]" ", "}}) Stream[U] {
+	return &zipWith{{.}}Stream[{{join . "T#" ", "}}, U]{ {{- join . "s#: s#" ", "}}, fn: fn}
+}
+{{end}}`))
+
+func join(from int, to int, format string, sep string) string {
+	parts := make([]string, 0, to-from+1)
+	for i := from; i <= to; i++ {
+		parts = append(parts, strings.ReplaceAll(format, "#", fmt.Sprint(i)))
+	}
+	return strings.Join(parts, sep)
+}
+
+func main() {
+	arity := flag.Int("max", 5, "highest arity to generate")
+	out := flag.String("o", "map_gen.go", "output file")
+	flag.Parse()
+	if *arity < 2 {
+		log.Fatal("genmap: -max must be at least 2")
+	}
+
+	data := struct{ Maps, Zips []int }{}
+	for n := 1; n <= *arity; n++ {
+		data.Maps = append(data.Maps, n)
+		if n >= 2 {
+			data.Zips = append(data.Zips, n)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		log.Fatalf("genmap: %v", err)
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatalf("genmap: %v\n%s", err, buf.Bytes())
+	}
+	if err := os.WriteFile(*out, src, 0o644); err != nil {
+		log.Fatalf("genmap: %v", err)
+	}
+}
@@ -0,0 +1,227 @@
+package vino
+
+import (
+	"cmp"
+	"sort"
+)
+
+// Number is the set of the built-in numeric types, and of the types
+// derived from them, that support arithmetic.
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64
+}
+
+// ------------------------------------------------------------------------
+//  Collectors
+// ------------------------------------------------------------------------
+//
+// Every collector comes in two flavours: a Slice one working on a slice,
+// and a Stream one that drains a stream and stops at its first failure,
+// returning what has been gathered so far along with the error. Both are
+// built on top of the same fold over an each function.
+
+// eachFunc calls f on every element of a source and reports the failure
+// that stopped it, if any.
+type eachFunc[T any] func(f func(T)) error
+
+func sliceEach[T any](s []T) eachFunc[T] {
+	return func(f func(T)) error {
+		for _, x := range s {
+			f(x)
+		}
+		return nil
+	}
+}
+
+func streamEach[T any](s Stream[T]) eachFunc[T] {
+	return func(f func(T)) error {
+		return StreamForEach(s, f)
+	}
+}
+
+func groupBy[T any, K comparable](each eachFunc[T], key func(T) K) (map[K][]T, error) {
+	ret := make(map[K][]T)
+	err := each(func(x T) {
+		k := key(x)
+		ret[k] = append(ret[k], x)
+	})
+	return ret, err
+}
+
+// SliceGroupBy groups the elements of s by the key returned by key,
+// keeping their relative order within each group.
+func SliceGroupBy[T any, K comparable](s []T, key func(T) K) map[K][]T {
+	ret, _ := groupBy(sliceEach(s), key)
+	return ret
+}
+
+// StreamGroupBy is the Stream counterpart of SliceGroupBy.
+func StreamGroupBy[T any, K comparable](s Stream[T], key func(T) K) (map[K][]T, error) {
+	return groupBy(streamEach(s), key)
+}
+
+func partition[T any](each eachFunc[T], filter FilterFunc[T]) ([]T, []T, error) {
+	kept, filtered := make([]T, 0), make([]T, 0)
+	err := each(func(x T) {
+		if filter.Test(x) {
+			filtered = append(filtered, x)
+		} else {
+			kept = append(kept, x)
+		}
+	})
+	return kept, filtered, err
+}
+
+// SlicePartition splits s in two. The first slice holds the elements
+// for which filter returns false, which is what FunctionalFilter would
+// return, and the second one holds the elements filtered out. A nil
+// filter keeps everything.
+func SlicePartition[T any](s []T, filter FilterFunc[T]) ([]T, []T) {
+	kept, filtered, _ := partition(sliceEach(s), filter)
+	return kept, filtered
+}
+
+// StreamPartition is the Stream counterpart of SlicePartition.
+func StreamPartition[T any](s Stream[T], filter FilterFunc[T]) ([]T, []T, error) {
+	return partition(streamEach(s), filter)
+}
+
+func countBy[T any, K comparable](each eachFunc[T], key func(T) K) (map[K]int, error) {
+	ret := make(map[K]int)
+	err := each(func(x T) {
+		ret[key(x)]++
+	})
+	return ret, err
+}
+
+// SliceCountBy counts the elements of s sharing the same key.
+func SliceCountBy[T any, K comparable](s []T, key func(T) K) map[K]int {
+	ret, _ := countBy(sliceEach(s), key)
+	return ret
+}
+
+// StreamCountBy is the Stream counterpart of SliceCountBy.
+func StreamCountBy[T any, K comparable](s Stream[T], key func(T) K) (map[K]int, error) {
+	return countBy(streamEach(s), key)
+}
+
+func sumBy[T any, K comparable, N Number](each eachFunc[T], key func(T) K, val func(T) N) (map[K]N, error) {
+	ret := make(map[K]N)
+	err := each(func(x T) {
+		ret[key(x)] += val(x)
+	})
+	return ret, err
+}
+
+// SliceSumBy sums val over the elements of s sharing the same key.
+func SliceSumBy[T any, K comparable, N Number](s []T, key func(T) K, val func(T) N) map[K]N {
+	ret, _ := sumBy(sliceEach(s), key, val)
+	return ret
+}
+
+// StreamSumBy is the Stream counterpart of SliceSumBy.
+func StreamSumBy[T any, K comparable, N Number](s Stream[T], key func(T) K, val func(T) N) (map[K]N, error) {
+	return sumBy(streamEach(s), key, val)
+}
+
+func bestBy[T any, K comparable](each eachFunc[T], key func(T) K, better func(T, T) bool) (map[K]T, error) {
+	ret := make(map[K]T)
+	err := each(func(x T) {
+		k := key(x)
+		if y, ok := ret[k]; !ok || better(x, y) {
+			ret[k] = x
+		}
+	})
+	return ret, err
+}
+
+// SliceMinBy returns the smallest element of each group of elements of s
+// sharing the same key, as ordered by cmp. The first of several smallest
+// elements wins.
+func SliceMinBy[T any, K comparable](s []T, key func(T) K, cmp func(T, T) int) map[K]T {
+	ret, _ := bestBy(sliceEach(s), key, func(x, y T) bool { return cmp(x, y) < 0 })
+	return ret
+}
+
+// StreamMinBy is the Stream counterpart of SliceMinBy.
+func StreamMinBy[T any, K comparable](s Stream[T], key func(T) K, cmp func(T, T) int) (map[K]T, error) {
+	return bestBy(streamEach(s), key, func(x, y T) bool { return cmp(x, y) < 0 })
+}
+
+// SliceMaxBy returns the largest element of each group of elements of s
+// sharing the same key, as ordered by cmp. The first of several largest
+// elements wins.
+func SliceMaxBy[T any, K comparable](s []T, key func(T) K, cmp func(T, T) int) map[K]T {
+	ret, _ := bestBy(sliceEach(s), key, func(x, y T) bool { return cmp(x, y) > 0 })
+	return ret
+}
+
+// StreamMaxBy is the Stream counterpart of SliceMaxBy.
+func StreamMaxBy[T any, K comparable](s Stream[T], key func(T) K, cmp func(T, T) int) (map[K]T, error) {
+	return bestBy(streamEach(s), key, func(x, y T) bool { return cmp(x, y) > 0 })
+}
+
+func histogram[T any, N cmp.Ordered](each eachFunc[T], val func(T) N, bounds []N) ([]int, error) {
+	ret := make([]int, len(bounds)+1)
+	err := each(func(x T) {
+		v := val(x)
+		ret[sort.Search(len(bounds), func(i int) bool { return bounds[i] > v })]++
+	})
+	return ret, err
+}
+
+// SliceHistogram buckets the elements of s by val according to the sorted
+// boundaries bounds, and returns the number of elements in each of the
+// len(bounds)+1 buckets. Bucket i holds the values in [bounds[i-1],
+// bounds[i]), the first bucket being unbounded below and the last one
+// unbounded above.
+func SliceHistogram[T any, N cmp.Ordered](s []T, val func(T) N, bounds []N) []int {
+	ret, _ := histogram(sliceEach(s), val, bounds)
+	return ret
+}
+
+// StreamHistogram is the Stream counterpart of SliceHistogram.
+func StreamHistogram[T any, N cmp.Ordered](s Stream[T], val func(T) N, bounds []N) ([]int, error) {
+	return histogram(streamEach(s), val, bounds)
+}
+
+// ------------------------------------------------------------------------
+//  Incremental Aggregation
+// ------------------------------------------------------------------------
+
+type aggregateByStream[T any, K comparable, A any] struct {
+	s    Stream[T]
+	key  func(T) K
+	init A
+	fold func(A, T) A
+	aggs map[K]A
+}
+
+func (s *aggregateByStream[T, K, A]) Next() (Pair[K, A], error) {
+	x, err := s.s.Next()
+	if err != nil {
+		return Pair[K, A]{}, err
+	}
+	k := s.key(x)
+	acc, ok := s.aggs[k]
+	if !ok {
+		acc = s.init
+	}
+	acc = s.fold(acc, x)
+	s.aggs[k] = acc
+	return Pair[K, A]{Left: k, Right: acc}, nil
+}
+
+func (s *aggregateByStream[T, K, A]) Close() error {
+	return StreamClose(s.s)
+}
+
+// StreamAggregateBy returns a stream that keeps a running aggregate per
+// key over the elements of s. For every element x, the aggregate of its
+// key, starting from init, is folded with x and yielded along with the
+// key. The last pair yielded for a key thus holds its final aggregate.
+func StreamAggregateBy[T any, K comparable, A any](s Stream[T], key func(T) K, init A, fold func(A, T) A) Stream[Pair[K, A]] {
+	return &aggregateByStream[T, K, A]{s: s, key: key, init: init, fold: fold, aggs: make(map[K]A)}
+}
@@ -0,0 +1,61 @@
+package vino_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	. "github.com/humbornjo/vino"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectors(t *testing.T) {
+	words := []string{"apple", "avocado", "banana", "blueberry", "cherry", "apricot"}
+	first := func(s string) byte { return s[0] }
+
+	assert.Equal(t, map[byte][]string{
+		'a': {"apple", "avocado", "apricot"},
+		'b': {"banana", "blueberry"},
+		'c': {"cherry"},
+	}, SliceGroupBy(words, first))
+
+	assert.Equal(t, map[byte]int{'a': 3, 'b': 2, 'c': 1}, SliceCountBy(words, first))
+
+	length := func(s string) int { return len(s) }
+	assert.Equal(t, map[byte]int{'a': 19, 'b': 15, 'c': 6}, SliceSumBy(words, first, length))
+
+	byLen := func(x, y string) int { return len(x) - len(y) }
+	assert.Equal(t, map[byte]string{'a': "apple", 'b': "banana", 'c': "cherry"}, SliceMinBy(words, first, byLen))
+	assert.Equal(t, map[byte]string{'a': "avocado", 'b': "blueberry", 'c': "cherry"}, SliceMaxBy(words, first, byLen))
+
+	kept, filtered := SlicePartition(words, func(s string) bool { return strings.HasPrefix(s, "a") })
+	assert.Equal(t, []string{"banana", "blueberry", "cherry"}, kept)
+	assert.Equal(t, []string{"apple", "avocado", "apricot"}, filtered)
+
+	hist := SliceHistogram(words, length, []int{6, 8})
+	assert.Equal(t, []int{1, 4, 1}, hist)
+}
+
+func TestStreamCollectors(t *testing.T) {
+	groups, err := StreamGroupBy(streamOf(1, 2, 3, 4, 5), func(x int) bool { return x%2 == 0 })
+	assert.NoError(t, err)
+	assert.Equal(t, map[bool][]int{false: {1, 3, 5}, true: {2, 4}}, groups)
+
+	errBoom := errors.New("boom")
+	counts, err := StreamCountBy[int](&failingStream{n: 3, err: errBoom}, func(x int) int { return x % 2 })
+	assert.ErrorIs(t, err, errBoom)
+	assert.Equal(t, map[int]int{0: 2, 1: 1}, counts)
+
+	hist, err := StreamHistogram(streamOf(0.5, 1.0, 1.5, 10.0), func(x float64) float64 { return x }, []float64{1, 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 1}, hist)
+}
+
+func TestStreamAggregateBy(t *testing.T) {
+	s := StreamAggregateBy(streamOf("a1", "b2", "a3"), func(x string) byte { return x[0] }, 0, func(acc int, x string) int {
+		return acc + int(x[1]-'0')
+	})
+	got, err := StreamCollect(s)
+	assert.NoError(t, err)
+	assert.Equal(t, []Pair[byte, int]{{'a', 1}, {'b', 2}, {'a', 4}}, got)
+}
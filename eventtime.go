@@ -0,0 +1,165 @@
+package vino
+
+import (
+	"container/heap"
+	"errors"
+	"time"
+)
+
+// WatermarkGenerator tracks the progress of event time. Observe is called
+// with the timestamp of every element that is not late, and returns the
+// current watermark: the promise that no element older than it is still
+// expected. A watermark never needs to move backwards; stages ignore it
+// when it does.
+type WatermarkGenerator interface {
+	Observe(ts time.Time) time.Time
+}
+
+type boundedLatenessWatermark struct {
+	lateness time.Duration
+	max      time.Time
+}
+
+func (w *boundedLatenessWatermark) Observe(ts time.Time) time.Time {
+	if ts.After(w.max) {
+		w.max = ts
+	}
+	return w.max.Add(-w.lateness)
+}
+
+// NewBoundedLatenessWatermark returns a WatermarkGenerator that trails
+// the largest timestamp observed so far by lateness, tolerating elements
+// that are out of order by up to lateness. The generator is stateful and
+// must not be shared between stages.
+func NewBoundedLatenessWatermark(lateness time.Duration) WatermarkGenerator {
+	return &boundedLatenessWatermark{lateness: lateness}
+}
+
+// EventTimeConfig tunes StreamEventTimeWindow.
+//
+// Fields:
+//   - Timestamp: Extracts the event time of an element.
+//   - Watermark: Generates the watermark from the observed timestamps.
+//     Defaults to NewBoundedLatenessWatermark(0), which assumes the
+//     elements arrive in order.
+//   - Late: Receives the elements whose timestamp is older than the
+//     watermark at the time they arrive. Late elements are dropped when
+//     it is nil.
+type EventTimeConfig[T any] struct {
+	Timestamp func(T) time.Time
+	Watermark WatermarkGenerator
+	Late      func(T)
+}
+
+type eventTimeEntry[T any] struct {
+	timed[T]
+	seq int
+}
+
+// eventTimeHeap orders the pending elements by timestamp, then by arrival
+// so that elements sharing a timestamp keep their order.
+type eventTimeHeap[T any] []eventTimeEntry[T]
+
+func (h eventTimeHeap[T]) Len() int {
+	return len(h)
+}
+
+func (h eventTimeHeap[T]) Less(i, j int) bool {
+	if h[i].at.Equal(h[j].at) {
+		return h[i].seq < h[j].seq
+	}
+	return h[i].at.Before(h[j].at)
+}
+
+func (h eventTimeHeap[T]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+}
+
+func (h *eventTimeHeap[T]) Push(x any) {
+	*h = append(*h, x.(eventTimeEntry[T]))
+}
+
+func (h *eventTimeHeap[T]) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+type eventTimeStream[T any] struct {
+	s         Stream[T]
+	w         windower[T]
+	config    EventTimeConfig[T]
+	watermark time.Time
+	pending   eventTimeHeap[T]
+	seq       int
+	ready     []Window[T]
+	done      bool
+}
+
+func (s *eventTimeStream[T]) Next() (Window[T], error) {
+	for len(s.ready) == 0 {
+		if s.done {
+			return Window[T]{}, ErrStreamExhausted
+		}
+		x, err := s.s.Next()
+		if errors.Is(err, ErrStreamExhausted) {
+			s.done = true
+			s.release(func(time.Time) bool { return true })
+			s.ready = append(s.ready, s.w.flush()...)
+			continue
+		}
+		if err != nil {
+			return Window[T]{}, err
+		}
+
+		ts := s.config.Timestamp(x)
+		if ts.Before(s.watermark) {
+			if s.config.Late != nil {
+				s.config.Late(x)
+			}
+			continue
+		}
+		heap.Push(&s.pending, eventTimeEntry[T]{timed: timed[T]{at: ts, x: x}, seq: s.seq})
+		s.seq++
+
+		if wm := s.config.Watermark.Observe(ts); wm.After(s.watermark) {
+			s.watermark = wm
+			s.release(func(at time.Time) bool { return at.Before(wm) })
+			s.ready = append(s.ready, s.w.tick(wm)...)
+		}
+	}
+	w := s.ready[0]
+	s.ready = s.ready[1:]
+	return w, nil
+}
+
+// release feeds the windower, in timestamp order, with the pending
+// elements accepted by final. Those are the elements no later arrival can
+// precede anymore.
+func (s *eventTimeStream[T]) release(final func(time.Time) bool) {
+	for s.pending.Len() > 0 && final(s.pending[0].at) {
+		e := heap.Pop(&s.pending).(eventTimeEntry[T])
+		s.ready = append(s.ready, s.w.add(e.at, e.x)...)
+	}
+}
+
+func (s *eventTimeStream[T]) Close() error {
+	return StreamClose(s.s)
+}
+
+// StreamEventTimeWindow returns a stream that groups the elements of s
+// into the windows described by spec according to their event time rather
+// than their arrival time. Elements are held back until the watermark
+// passes them, then handed to the windows in timestamp order, and a window
+// is emitted once the watermark reaches its end. Elements arriving with a
+// timestamp older than the watermark are late and go to config.Late. Once
+// s is exhausted, everything still held back is emitted.
+//
+// The Start and End of the emitted windows are expressed in event time.
+func StreamEventTimeWindow[T any](s Stream[T], spec WindowSpec[T], config EventTimeConfig[T]) Stream[Window[T]] {
+	if config.Watermark == nil {
+		config.Watermark = NewBoundedLatenessWatermark(0)
+	}
+	return &eventTimeStream[T]{s: s, w: spec.newWindower(), config: config}
+}
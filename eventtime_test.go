@@ -0,0 +1,52 @@
+package vino_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/humbornjo/vino"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamEventTimeWindow(t *testing.T) {
+	epoch := time.Unix(0, 0)
+	at := func(sec int) time.Time { return epoch.Add(time.Duration(sec) * time.Second) }
+
+	late := []int{}
+	s := StreamEventTimeWindow(streamOf(1, 3, 2, 6, 4, 11, 3, 12), TumblingTimeWindow[int](5*time.Second), EventTimeConfig[int]{
+		Timestamp: at,
+		Watermark: NewBoundedLatenessWatermark(2 * time.Second),
+		Late:      func(x int) { late = append(late, x) },
+	})
+
+	ws, err := StreamCollect(s)
+	assert.NoError(t, err)
+	assert.Equal(t, []Window[int]{
+		{Start: at(0), End: at(5), Items: []int{1, 2, 3, 4}},
+		{Start: at(5), End: at(10), Items: []int{6}},
+		{Start: at(10), End: at(15), Items: []int{11, 12}},
+	}, ws)
+	assert.Equal(t, []int{3}, late)
+}
+
+func TestStreamEventTimeWindow_Session(t *testing.T) {
+	epoch := time.Unix(0, 0)
+	at := func(sec int) time.Time { return epoch.Add(time.Duration(sec) * time.Second) }
+
+	// Without lateness, out of order elements are late and dropped.
+	s := StreamEventTimeWindow(streamOf(1, 2, 0, 10, 11, 30), SessionWindow[int](5*time.Second), EventTimeConfig[int]{
+		Timestamp: at,
+	})
+
+	x, err := s.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, x.Items)
+	assert.Equal(t, at(1), x.Start)
+	assert.Equal(t, at(2), x.End)
+
+	rest, err := StreamCollect(s)
+	assert.NoError(t, err)
+	assert.Len(t, rest, 2)
+	assert.Equal(t, []int{10, 11}, rest[0].Items)
+	assert.Equal(t, []int{30}, rest[1].Items)
+}
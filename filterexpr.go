@@ -0,0 +1,641 @@
+package vino
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ExprError reports an invalid filter expression, along with the byte
+// offset in the expression of the token at fault.
+type ExprError struct {
+	Offset int
+	Err    error
+}
+
+func (e *ExprError) Error() string {
+	return fmt.Sprintf("filter expression (offset %d): %v", e.Offset, e.Err)
+}
+
+func (e *ExprError) Unwrap() error {
+	return e.Err
+}
+
+func exprErrorf(offset int, format string, args ...any) error {
+	return &ExprError{Offset: offset, Err: fmt.Errorf(format, args...)}
+}
+
+// CompileFilter compiles a boolean expression over the fields of the
+// struct type T, or pointer to struct, into a FilterFunc that holds for
+// the elements the expression is true for. Keep in mind that a FilterFunc
+// holding means the element is filtered out, so negate the expression to
+// describe the elements to keep.
+//
+// The expression language supports:
+//   - Field paths such as age or address.city, resolved through embedded
+//     and nested structs. A field with a filter tag, such as
+//     `filter:"city"`, matches the segment equal to the tag, and a field
+//     without one matches its name regardless of case. Only exported
+//     fields can be used. A path through a nil pointer, including a nil
+//     *T, makes the comparison it appears in false. Fields named in, true
+//     or false cannot be referenced by name, give them a filter tag.
+//   - Literals: double-quoted strings with Go escapes, numbers, true and
+//     false.
+//   - Comparisons with ==, !=, <, <=, > and >= between a field and a
+//     literal or another field of the same type. Fields of type string,
+//     bool, integer and float are supported, and bools can only be compared
+//     for equality. As in Go, NaN is unequal to everything, itself
+//     included.
+//   - Membership: field in [literal, ...].
+//   - Regular expressions: field =~ "pattern", for string fields.
+//   - Bool fields on their own, and boolean logic with !, && and ||,
+//     grouped by parentheses.
+//
+// Types are checked when compiling, and any error is an *ExprError giving
+// the offset of the offending token.
+//
+// Example:
+//
+//	type User struct {
+//	    Status string
+//	    Age    int
+//	    Name   string `filter:"name"`
+//	}
+//	drop, err := CompileFilter[User](`!(status == "active" && age >= 30 && name =~ "^j")`)
+//	kept := FunctionalFilter(users, drop)
+func CompileFilter[T any](expr string) (FilterFunc[T], error) {
+	t := reflect.TypeFor[T]()
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, exprErrorf(0, "%v is not a struct", reflect.TypeFor[T]())
+	}
+
+	tokens, err := lexExpr(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{t: t, tokens: tokens}
+	eval, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, exprErrorf(tok.pos, "unexpected %s", tok)
+	}
+
+	return func(x T) bool {
+		return eval(reflect.ValueOf(&x).Elem())
+	}, nil
+}
+
+// ------------------------------------------------------------------------
+//  Lexer
+// ------------------------------------------------------------------------
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenOp
+)
+
+type exprToken struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t exprToken) String() string {
+	if t.kind == tokenEOF {
+		return "end of expression"
+	}
+	return strconv.Quote(t.text)
+}
+
+// exprOps lists the operators, the two-character ones first so that they
+// take precedence over their prefixes.
+var exprOps = []string{"==", "!=", "<=", ">=", "=~", "&&", "||", "<", ">", "!", "(", ")", "[", "]", ","}
+
+func lexExpr(expr string) ([]exprToken, error) {
+	var tokens []exprToken
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '"':
+			j := i + 1
+			for j < len(expr) && expr[j] != '"' {
+				if expr[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(expr) {
+				return nil, exprErrorf(i, "unterminated string")
+			}
+			s, err := strconv.Unquote(expr[i : j+1])
+			if err != nil {
+				return nil, &ExprError{Offset: i, Err: err}
+			}
+			tokens = append(tokens, exprToken{kind: tokenString, text: s, pos: i})
+			i = j + 1
+		case c == '-' || c >= '0' && c <= '9':
+			j := i + 1
+			for j < len(expr) && (isIdentByte(expr[j]) || expr[j] == '.' ||
+				(expr[j] == '-' || expr[j] == '+') && (expr[j-1] == 'e' || expr[j-1] == 'E')) {
+				j++
+			}
+			tokens = append(tokens, exprToken{kind: tokenNumber, text: expr[i:j], pos: i})
+			i = j
+		case isIdentByte(c):
+			j := i
+			for j < len(expr) && (isIdentByte(expr[j]) || expr[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, exprToken{kind: tokenIdent, text: expr[i:j], pos: i})
+			i = j
+		default:
+			op := ""
+			for _, o := range exprOps {
+				if strings.HasPrefix(expr[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, exprErrorf(i, "unexpected character %q", c)
+			}
+			tokens = append(tokens, exprToken{kind: tokenOp, text: op, pos: i})
+			i += len(op)
+		}
+	}
+	return append(tokens, exprToken{kind: tokenEOF, pos: len(expr)}), nil
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// ------------------------------------------------------------------------
+//  Parser
+// ------------------------------------------------------------------------
+
+// exprType is the type of an operand once fields are reduced to the kinds
+// the language supports.
+type exprType int
+
+const (
+	exprString exprType = iota
+	exprBool
+	exprInt
+	exprUint
+	exprFloat
+	// exprNumber is the type of an integer literal, which adapts to the
+	// type of the field it is compared with.
+	exprNumber
+)
+
+func (t exprType) String() string {
+	return [...]string{"string", "bool", "int", "uint", "float", "number"}[t]
+}
+
+// exprOperand is either a field, which is read from the struct being
+// filtered, or a literal.
+type exprOperand struct {
+	tok   exprToken
+	typ   exprType
+	index [][]int
+	value any
+}
+
+func (o exprOperand) isField() bool {
+	return o.index != nil
+}
+
+// get reads the value of the operand, normalized to string, bool, int64,
+// uint64 or float64. It reports false if the path to a field goes through
+// a nil pointer.
+func (o exprOperand) get(v reflect.Value) (any, bool) {
+	if !o.isField() {
+		return o.value, true
+	}
+	for _, index := range o.index {
+		for _, i := range index {
+			for v.Kind() == reflect.Pointer {
+				if v.IsNil() {
+					return nil, false
+				}
+				v = v.Elem()
+			}
+			v = v.Field(i)
+		}
+	}
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil, false
+		}
+		v = v.Elem()
+	}
+	switch o.typ {
+	case exprString:
+		return v.String(), true
+	case exprBool:
+		return v.Bool(), true
+	case exprInt:
+		return v.Int(), true
+	case exprUint:
+		return v.Uint(), true
+	default:
+		return v.Float(), true
+	}
+}
+
+type exprParser struct {
+	t      reflect.Type
+	tokens []exprToken
+	i      int
+}
+
+func (p *exprParser) peek() exprToken {
+	return p.tokens[p.i]
+}
+
+func (p *exprParser) next() exprToken {
+	tok := p.tokens[p.i]
+	if tok.kind != tokenEOF {
+		p.i++
+	}
+	return tok
+}
+
+// accept consumes the next token if it is the operator op.
+func (p *exprParser) accept(op string) bool {
+	if tok := p.peek(); tok.kind == tokenOp && tok.text == op {
+		p.i++
+		return true
+	}
+	return false
+}
+
+func (p *exprParser) expect(op string) error {
+	if !p.accept(op) {
+		tok := p.peek()
+		return exprErrorf(tok.pos, "expected %q, found %s", op, tok)
+	}
+	return nil
+}
+
+type exprEval func(reflect.Value) bool
+
+func (p *exprParser) parseOr() (exprEval, error) {
+	lhs, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("||") {
+		rhs, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := lhs
+		lhs = func(v reflect.Value) bool { return l(v) || rhs(v) }
+	}
+	return lhs, nil
+}
+
+func (p *exprParser) parseAnd() (exprEval, error) {
+	lhs, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("&&") {
+		rhs, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l := lhs
+		lhs = func(v reflect.Value) bool { return l(v) && rhs(v) }
+	}
+	return lhs, nil
+}
+
+func (p *exprParser) parseUnary() (exprEval, error) {
+	if p.accept("!") {
+		e, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(v reflect.Value) bool { return !e(v) }, nil
+	}
+	if p.accept("(") {
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return e, p.expect(")")
+	}
+	return p.parseComparison()
+}
+
+func (p *exprParser) parseComparison() (exprEval, error) {
+	lhs, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	tok := p.peek()
+	switch {
+	case tok.kind == tokenIdent && tok.text == "in":
+		p.next()
+		return p.parseIn(lhs)
+	case tok.kind == tokenOp && tok.text == "=~":
+		p.next()
+		return p.parseMatch(lhs)
+	case tok.kind == tokenOp && slices.Contains([]string{"==", "!=", "<", "<=", ">", ">="}, tok.text):
+		p.next()
+		rhs, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return compareOperands(tok, lhs, rhs)
+	}
+
+	// A bool operand stands for itself.
+	if lhs.typ != exprBool {
+		return nil, exprErrorf(tok.pos, "expected a comparison after %s, found %s", lhs.tok, tok)
+	}
+	return func(v reflect.Value) bool {
+		x, ok := lhs.get(v)
+		return ok && x.(bool)
+	}, nil
+}
+
+func (p *exprParser) parseOperand() (exprOperand, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenString:
+		return exprOperand{tok: tok, typ: exprString, value: tok.text}, nil
+	case tokenNumber:
+		if isIntegerText(tok.text) {
+			return exprOperand{tok: tok, typ: exprNumber, value: tok.text}, nil
+		}
+		f, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return exprOperand{}, exprErrorf(tok.pos, "invalid number %s", tok)
+		}
+		return exprOperand{tok: tok, typ: exprFloat, value: f}, nil
+	case tokenIdent:
+		switch tok.text {
+		case "true", "false":
+			return exprOperand{tok: tok, typ: exprBool, value: tok.text == "true"}, nil
+		case "in":
+			return exprOperand{}, exprErrorf(tok.pos, "unexpected %s", tok)
+		}
+		return p.resolveField(tok)
+	}
+	return exprOperand{}, exprErrorf(tok.pos, "expected a field or a literal, found %s", tok)
+}
+
+// isIntegerText tells whether text is an integer literal, whether or not
+// it fits the type of the field it is compared with.
+func isIntegerText(text string) bool {
+	digits := strings.TrimPrefix(text, "-")
+	return digits != "" && strings.Trim(digits, "0123456789") == ""
+}
+
+// resolveField looks the path of tok up in the struct being filtered.
+func (p *exprParser) resolveField(tok exprToken) (exprOperand, error) {
+	o := exprOperand{tok: tok}
+	t := p.t
+	offset := tok.pos
+	for _, name := range strings.Split(tok.text, ".") {
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct || name == "" {
+			return o, exprErrorf(offset, "cannot select %q from %v", name, t)
+		}
+		f, ok := lookupField(t, name)
+		if !ok {
+			return o, exprErrorf(offset, "unknown field %q in %v", name, t)
+		}
+		o.index = append(o.index, f.Index)
+		t = f.Type
+		offset += len(name) + 1
+	}
+
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		o.typ = exprString
+	case reflect.Bool:
+		o.typ = exprBool
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		o.typ = exprInt
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		o.typ = exprUint
+	case reflect.Float32, reflect.Float64:
+		o.typ = exprFloat
+	default:
+		return o, exprErrorf(tok.pos, "field %s has unsupported type %v", tok.text, t)
+	}
+	return o, nil
+}
+
+// lookupField finds the exported field of t whose filter tag is name, or
+// else the untagged one whose name is name regardless of case.
+func lookupField(t reflect.Type, name string) (reflect.StructField, bool) {
+	fields := reflect.VisibleFields(t)
+	for _, f := range fields {
+		if f.IsExported() && f.Tag.Get("filter") == name {
+			return f, true
+		}
+	}
+	for _, f := range fields {
+		if f.IsExported() && !f.Anonymous && f.Tag.Get("filter") == "" && strings.EqualFold(f.Name, name) {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// coerce converts the literal o to the type typ of the field it is
+// compared with.
+func coerce(o exprOperand, typ exprType) (any, error) {
+	switch {
+	case o.typ == typ:
+		return o.value, nil
+	case o.typ == exprNumber && typ == exprInt:
+		x, err := strconv.ParseInt(o.value.(string), 10, 64)
+		if err != nil {
+			return nil, exprErrorf(o.tok.pos, "%s is not a valid int", o.tok.text)
+		}
+		return x, nil
+	case o.typ == exprNumber && typ == exprUint:
+		x, err := strconv.ParseUint(o.value.(string), 10, 64)
+		if err != nil {
+			return nil, exprErrorf(o.tok.pos, "%s is not a valid uint", o.tok.text)
+		}
+		return x, nil
+	case o.typ == exprNumber && typ == exprFloat:
+		return strconv.ParseFloat(o.value.(string), 64)
+	}
+	return nil, exprErrorf(o.tok.pos, "cannot compare %v field with %v %s", typ, o.typ, o.tok)
+}
+
+// compareValues compares two values normalized by exprOperand.get. It
+// reports false if they are unordered, which is the case when either one
+// is NaN.
+func compareValues(a any, b any) (int, bool) {
+	switch a := a.(type) {
+	case string:
+		return cmp.Compare(a, b.(string)), true
+	case int64:
+		return cmp.Compare(a, b.(int64)), true
+	case uint64:
+		return cmp.Compare(a, b.(uint64)), true
+	case float64:
+		if b := b.(float64); !math.IsNaN(a) && !math.IsNaN(b) {
+			return cmp.Compare(a, b), true
+		}
+		return 0, false
+	case bool:
+		if a == b.(bool) {
+			return 0, true
+		}
+		return 1, true
+	}
+	return 1, true
+}
+
+// mirror returns the operator that gives the same result once its
+// operands are swapped.
+var mirror = map[string]string{"==": "==", "!=": "!=", "<": ">", "<=": ">=", ">": "<", ">=": "<="}
+
+func compareOperands(op exprToken, lhs exprOperand, rhs exprOperand) (exprEval, error) {
+	if !lhs.isField() {
+		if !rhs.isField() {
+			return nil, exprErrorf(lhs.tok.pos, "comparison needs a field, found %s and %s", lhs.tok, rhs.tok)
+		}
+		lhs, rhs = rhs, lhs
+		op.text = mirror[op.text]
+	}
+
+	if rhs.isField() {
+		if lhs.typ != rhs.typ {
+			return nil, exprErrorf(rhs.tok.pos, "cannot compare %v field %s with %v field %s", lhs.typ, lhs.tok.text, rhs.typ, rhs.tok.text)
+		}
+	} else {
+		value, err := coerce(rhs, lhs.typ)
+		if err != nil {
+			return nil, err
+		}
+		rhs.value, rhs.typ = value, lhs.typ
+	}
+	if lhs.typ == exprBool && op.text != "==" && op.text != "!=" {
+		return nil, exprErrorf(op.pos, "operator %s is not defined on bool", op.text)
+	}
+
+	var test func(int) bool
+	switch op.text {
+	case "==":
+		test = func(c int) bool { return c == 0 }
+	case "!=":
+		test = func(c int) bool { return c != 0 }
+	case "<":
+		test = func(c int) bool { return c < 0 }
+	case "<=":
+		test = func(c int) bool { return c <= 0 }
+	case ">":
+		test = func(c int) bool { return c > 0 }
+	default:
+		test = func(c int) bool { return c >= 0 }
+	}
+	return func(v reflect.Value) bool {
+		a, ok := lhs.get(v)
+		if !ok {
+			return false
+		}
+		b, ok := rhs.get(v)
+		if !ok {
+			return false
+		}
+		c, ordered := compareValues(a, b)
+		if !ordered {
+			return op.text == "!="
+		}
+		return test(c)
+	}, nil
+}
+
+func (p *exprParser) parseIn(lhs exprOperand) (exprEval, error) {
+	if !lhs.isField() {
+		return nil, exprErrorf(lhs.tok.pos, "expected a field before in, found %s", lhs.tok)
+	}
+	if err := p.expect("["); err != nil {
+		return nil, err
+	}
+	var values []any
+	for !p.accept("]") {
+		if len(values) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		o, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		if o.isField() {
+			return nil, exprErrorf(o.tok.pos, "expected a literal, found field %s", o.tok.text)
+		}
+		value, err := coerce(o, lhs.typ)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return func(v reflect.Value) bool {
+		x, ok := lhs.get(v)
+		if !ok {
+			return false
+		}
+		for _, y := range values {
+			if c, ordered := compareValues(x, y); ordered && c == 0 {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+func (p *exprParser) parseMatch(lhs exprOperand) (exprEval, error) {
+	if !lhs.isField() || lhs.typ != exprString {
+		return nil, exprErrorf(lhs.tok.pos, "=~ needs a string field, found %s", lhs.tok)
+	}
+	tok := p.next()
+	if tok.kind != tokenString {
+		return nil, exprErrorf(tok.pos, "expected a pattern string, found %s", tok)
+	}
+	re, err := regexp.Compile(tok.text)
+	if err != nil {
+		return nil, &ExprError{Offset: tok.pos, Err: err}
+	}
+	return func(v reflect.Value) bool {
+		x, ok := lhs.get(v)
+		return ok && re.MatchString(x.(string))
+	}, nil
+}
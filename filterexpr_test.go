@@ -0,0 +1,173 @@
+package vino_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	. "github.com/humbornjo/vino"
+	"github.com/stretchr/testify/assert"
+)
+
+type exprAddress struct {
+	City string
+	Zip  *uint
+}
+
+type exprMeta struct {
+	Score float64
+}
+
+type exprUser struct {
+	exprMeta
+	Status  string
+	Age     int
+	Name    string `filter:"login"`
+	Admin   bool
+	Limit   int
+	Address *exprAddress
+	Big     uint64
+	hidden  int
+}
+
+func TestCompileFilter(t *testing.T) {
+	zip := uint(10001)
+	users := []exprUser{
+		{Status: "active", Age: 35, Name: "jane", Admin: true, Limit: 40, Address: &exprAddress{City: "Paris", Zip: &zip}, exprMeta: exprMeta{Score: 0.5}},
+		{Status: "active", Age: 25, Name: "john", Limit: 20},
+		{Status: "banned", Age: 41, Name: "bob", Limit: 40, Address: &exprAddress{City: "Oslo"}, exprMeta: exprMeta{Score: 2}},
+	}
+
+	tests := []struct {
+		expr string
+		want []string
+	}{
+		{`status == "active" && age >= 30 && login =~ "^j"`, []string{"jane"}},
+		{`status == "active" || age > 40`, []string{"jane", "john", "bob"}},
+		{`!(status == "active")`, []string{"bob"}},
+		{`admin`, []string{"jane"}},
+		{`!admin && AGE < 30`, []string{"john"}},
+		{`admin == false`, []string{"john", "bob"}},
+		{`30 < age`, []string{"jane", "bob"}},
+		{`age < limit`, []string{"jane"}},
+		{`login in ["bob", "jane"]`, []string{"jane", "bob"}},
+		{`age in []`, nil},
+		{`address.city == "Oslo"`, []string{"bob"}},
+		{`address.city != "Oslo"`, []string{"jane"}},
+		{`address.zip == 10001`, []string{"jane"}},
+		{`score >= 1 || score == 0.5`, []string{"jane", "bob"}},
+		{`true && (age == 25 || age == 41)`, []string{"john", "bob"}},
+		{`login =~ "\\w{4}"`, []string{"jane", "john"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			f, err := CompileFilter[exprUser](tt.expr)
+			assert.NoError(t, err)
+			var got []string
+			for _, u := range users {
+				if f(u) {
+					got = append(got, u.Name)
+				}
+			}
+			assert.Equal(t, tt.want, got)
+
+			// Pointers to structs work the same.
+			g, err := CompileFilter[*exprUser](tt.expr)
+			assert.NoError(t, err)
+			for _, u := range users {
+				assert.Equal(t, f(u), g(&u))
+			}
+			assert.NotPanics(t, func() { g(nil) })
+		})
+	}
+
+	f, err := CompileFilter[exprUser](`!(age >= 30)`)
+	assert.NoError(t, err)
+	assert.Len(t, FunctionalFilter(users, f), 2)
+
+	// A nil *T only makes the comparisons false, not the whole expression.
+	g, err := CompileFilter[*exprUser](`!(age > 1) && !admin`)
+	assert.NoError(t, err)
+	assert.True(t, g(nil))
+
+	// Integers beyond int64 are still integers for uint fields.
+	f, err = CompileFilter[exprUser](`big == 18446744073709551615`)
+	assert.NoError(t, err)
+	assert.True(t, f(exprUser{Big: math.MaxUint64}))
+	assert.False(t, f(exprUser{Big: 1}))
+}
+
+func TestCompileFilter_NaN(t *testing.T) {
+	nan := exprUser{exprMeta: exprMeta{Score: math.NaN()}}
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`score < 1`, false},
+		{`score <= 1`, false},
+		{`score > 1`, false},
+		{`score >= 1`, false},
+		{`score == 1`, false},
+		{`score != 1`, true},
+		{`1 != score`, true},
+		{`score in [1, 2.5]`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			f, err := CompileFilter[exprUser](tt.expr)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, f(nan))
+		})
+	}
+}
+
+func TestCompileFilter_Errors(t *testing.T) {
+	tests := []struct {
+		expr   string
+		offset int
+		err    string
+	}{
+		{`age >= `, 7, `expected a field or a literal, found end of expression`},
+		{`age`, 3, `expected a comparison after "age", found end of expression`},
+		{`age == 1 &&`, 11, `expected a field or a literal, found end of expression`},
+		{`(age == 1`, 9, `expected ")", found end of expression`},
+		{`age == 1)`, 8, `unexpected ")"`},
+		{`age == 1 # 2`, 9, `unexpected character '#'`},
+		{`status == "active`, 10, `unterminated string`},
+		{`agee == 1`, 0, `unknown field "agee" in vino_test.exprUser`},
+		{`address.town == "x"`, 8, `unknown field "town" in vino_test.exprAddress`},
+		{`age.x == 1`, 4, `cannot select "x" from int`},
+		{`hidden == 1`, 0, `unknown field "hidden" in vino_test.exprUser`},
+		{`name == "x"`, 0, `unknown field "name" in vino_test.exprUser`},
+		{`address == 1`, 0, `field address has unsupported type vino_test.exprAddress`},
+		{`age == "30"`, 7, `cannot compare int field with string "30"`},
+		{`age == 1.5`, 7, `cannot compare int field with float "1.5"`},
+		{`address.zip == -1`, 15, `-1 is not a valid uint`},
+		{`age == 18446744073709551615`, 7, `18446744073709551615 is not a valid int`},
+		{`age == limit && score == age`, 25, `cannot compare float field score with int field age`},
+		{`admin < true`, 6, `operator < is not defined on bool`},
+		{`1 == 1`, 0, `comparison needs a field, found "1" and "1"`},
+		{`age in [1, "x"]`, 11, `cannot compare int field with string "x"`},
+		{`age in [1 2]`, 10, `expected ",", found "2"`},
+		{`age in [limit]`, 8, `expected a literal, found field limit`},
+		{`age =~ "x"`, 0, "=~ needs a string field, found \"age\""},
+		{`status =~ 1`, 10, `expected a pattern string, found "1"`},
+		{`status =~ "("`, 10, "error parsing regexp: missing closing ): `(`"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := CompileFilter[exprUser](tt.expr)
+			var exprErr *ExprError
+			if assert.True(t, errors.As(err, &exprErr)) {
+				assert.Equal(t, tt.offset, exprErr.Offset)
+				assert.EqualError(t, exprErr.Err, tt.err)
+			}
+		})
+	}
+
+	_, err := CompileFilter[int](`x == 1`)
+	assert.EqualError(t, err, "filter expression (offset 0): int is not a struct")
+}
@@ -2,6 +2,8 @@ package vino
 
 import (
 	"errors"
+	"fmt"
+	"io"
 	"reflect"
 )
 
@@ -12,16 +14,51 @@ type Stream[T any] interface {
 	Next() (T, error)
 }
 
+// ErrStreamExhausted is returned by Next when a stream has no more
+// elements. It marks the normal end of a stream, so any other error
+// returned by Next should be treated as a failure of the source.
+var ErrStreamExhausted = errors.New("stream exhausted")
+
+// StreamError annotates a failure raised while pulling from a stream with
+// the operation that observed it and the zero-based position of the
+// element being produced. The original error is kept for errors.Is and
+// errors.As.
+type StreamError struct {
+	Op    string
+	Index int
+	Err   error
+}
+
+func (e *StreamError) Error() string {
+	return fmt.Sprintf("%s: element %d: %v", e.Op, e.Index, e.Err)
+}
+
+func (e *StreamError) Unwrap() error {
+	return e.Err
+}
+
+// StreamClose releases the resources held by s, such as a file or a
+// connection, if it implements io.Closer, and does nothing otherwise. The adapters in this package
+// forward Close to the streams they wrap, so closing the outermost stream
+// of a pipeline is enough.
+func StreamClose[T any](s Stream[T]) error {
+	if c, ok := s.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
 type repeatedStream[T any] struct {
 	xs     []T
 	idx    int
 	repeat int
+	init   int
 }
 
-func (s repeatedStream[T]) Next() (T, error) {
+func (s *repeatedStream[T]) Next() (T, error) {
 	if s.idx >= len(s.xs) {
-		if s.repeat == 0 {
-			return *new(T), errors.New("stream exhausted")
+		if s.repeat == 0 || len(s.xs) == 0 {
+			return *new(T), ErrStreamExhausted
 		}
 		if s.repeat > 0 {
 			s.repeat--
@@ -29,13 +66,30 @@ func (s repeatedStream[T]) Next() (T, error) {
 		s.idx = 0
 	}
 	s.idx++
-	return s.xs[s.idx], nil
+	return s.xs[s.idx-1], nil
 }
 
+// NewRepeatedStream returns a stream over a copy of s that starts over
+// once the end is reached, repeat more times. A negative repeat makes the
+// stream endless.
 func NewRepeatedStream[T any](s []T, repeat int) Stream[T] {
-	xs := make([]T, 0, len(s))
+	xs := make([]T, len(s))
 	copy(xs, s)
-	return repeatedStream[T]{xs: xs, repeat: repeat, idx: 0}
+	return &repeatedStream[T]{xs: xs, repeat: repeat, idx: 0, init: repeat}
+}
+
+// Reset rewinds the stream to its very first element, restoring the
+// repeat count it was created with.
+func (s *repeatedStream[T]) Reset() error {
+	s.idx, s.repeat = 0, s.init
+	return nil
+}
+
+// Clone returns an independent stream positioned where s is. The
+// underlying slice is shared, which is safe since it is never written.
+func (s *repeatedStream[T]) Clone() (Stream[T], error) {
+	clone := *s
+	return &clone, nil
 }
 
 // FilterFunc is a function type that takes a value of type T and returns
@@ -45,12 +99,17 @@ type FilterFunc[T any] func(T) bool
 // Append adds a new filter function to the existing FilterFunc chain.
 // The resulting filter returns true if any filter in the chain returns
 // true for a given value.
-// Note: It is expected that a nil FilterFunc is treated as a function
-// that always returns false.
+// Note: A nil FilterFunc, be it the receiver or filter, is treated as a
+// function that always returns false. Use Or, AnyOf and the rest of the
+// predicate combinators to build filters that can explain themselves.
 func (p *FilterFunc[T]) Append(filter func(T) bool) {
 	f := *p
-	if f != nil {
-		f = func(_ T) bool { return false }
+	switch {
+	case filter == nil:
+		return
+	case f == nil:
+		*p = filter
+		return
 	}
 
 	*p = func(x T) bool {
@@ -60,11 +119,12 @@ func (p *FilterFunc[T]) Append(filter func(T) bool) {
 
 // FunctionalFilter applies the given filter to the slice xs and returns
 // a new slice containing only those elements for which the filter returns
-// false (i.e. elements that are not filtered out).
+// false (i.e. elements that are not filtered out). A nil filter keeps
+// everything.
 func FunctionalFilter[T any](xs []T, filter FilterFunc[T]) []T {
 	ret := make([]T, 0, len(xs))
 	for _, x := range xs {
-		if filter(x) {
+		if filter.Test(x) {
 			continue
 		}
 		ret = append(ret, x)
@@ -72,6 +132,8 @@ func FunctionalFilter[T any](xs []T, filter FilterFunc[T]) []T {
 	return ret
 }
 
+//go:generate go run ./cmd/genmap -max 5 -o map_gen.go
+
 // FunctionalMap applies the function fn to corresponding elements of the
 // provided slice arguments (xss) and returns a new slice of type T containing
 // the results. The following conditions must be met:
@@ -83,8 +145,27 @@ func FunctionalFilter[T any](xs []T, filter FilterFunc[T]) []T {
 //   - All input slices must have the same length.
 //   - fn must return exactly one output, whose type matches T.
 //
-// An error is returned if any of these conditions are not satisfied.
+// An error is returned if any of these conditions are not satisfied. An
+// error about a slice names it by its position in xss. Use
+// FunctionalMapAligned to map slices of different lengths.
+//
+// Map, Map2, ..., Map5 do the same with the types checked at compile time
+// and without the cost of reflection, and should be preferred when the
+// arity is known.
 func FunctionalMap[T any](fn any, xss ...any) ([]T, error) {
+	return FunctionalMapAligned[T](AlignStrict(), fn, xss...)
+}
+
+// FunctionalMapAligned is FunctionalMap with the slices lined up according
+// to align instead of having to share the same length.
+//
+// Example:
+//
+//	add := func(a, b int) int { return a + b }
+//	FunctionalMapAligned[int](AlignShortest(), add, []int{1, 2, 3}, []int{10, 20})   // [11 22]
+//	FunctionalMapAligned[int](AlignLongest(0, 100), add, []int{1, 2, 3}, []int{10}) // [11 102 103]
+//	FunctionalMapAligned[int](AlignBroadcast(), add, []int{1, 2, 3}, []int{10})     // [11 12 13]
+func FunctionalMapAligned[T any](align Alignment, fn any, xss ...any) ([]T, error) {
 	fnReflect := reflect.ValueOf(fn)
 	if fnReflect.Kind() != reflect.Func {
 		return nil, errors.New("fn is not a function pointer")
@@ -95,23 +176,9 @@ func FunctionalMap[T any](fn any, xss ...any) ([]T, error) {
 		return nil, errors.New("input parameter count mismatch")
 	}
 
-	N := 0
-	for i, n := 0, -1; i < inLen; i++ {
-		xsReflect := reflect.ValueOf(xss[i])
-		if xsReflect.Kind() != reflect.Slice {
-			return nil, errors.New("input parameter is not a slice")
-		}
-
-		if xsReflect.Type().Elem() != fnReflect.Type().In(i) {
-			return nil, errors.New("input parameter type mismatch")
-		}
-
-		if n == -1 {
-			n = xsReflect.Len()
-			N = n
-		} else if n != xsReflect.Len() {
-			return nil, errors.New("input parameter slice length mismatch")
-		}
+	in, err := functionalInputs(fnReflect.Type(), 0, xss, align)
+	if err != nil {
+		return nil, err
 	}
 
 	inArgs := make([]reflect.Type, inLen)
@@ -138,14 +205,155 @@ func FunctionalMap[T any](fn any, xss ...any) ([]T, error) {
 		return fnReflect.Call(args)
 	})
 
-	ret := make([]T, N)
-	for i := 0; i < N; i++ {
+	ret := make([]T, in.n)
+	for i := 0; i < in.n; i++ {
 		args := make([]reflect.Value, inLen)
 		for j := 0; j < inLen; j++ {
-			args[j] = reflect.ValueOf(xss[j]).Index(i)
+			args[j] = in.at(j, i)
 		}
 		ret[i] = fnDyn.Call(args)[0].Interface().(T)
 	}
 
 	return ret, nil
 }
+
+type alignMode int
+
+const (
+	alignStrict alignMode = iota
+	alignShortest
+	alignLongest
+	alignBroadcast
+)
+
+// Alignment tells FunctionalMapAligned how to line up slices of different
+// lengths. Use AlignStrict, AlignShortest, AlignLongest or AlignBroadcast
+// to create one.
+type Alignment struct {
+	mode  alignMode
+	fills []any
+}
+
+// AlignStrict requires all slices to have the same length, which is what
+// FunctionalMap does.
+func AlignStrict() Alignment {
+	return Alignment{mode: alignStrict}
+}
+
+// AlignShortest stops at the end of the shortest slice, dropping the
+// extra elements of the other ones.
+func AlignShortest() Alignment {
+	return Alignment{mode: alignShortest}
+}
+
+// AlignLongest goes on until the end of the longest slice, padding the
+// shorter ones. A slice is padded with the fill value at the same position
+// in fills, or with the zero value of its elements if there is none or it
+// is nil.
+func AlignLongest(fills ...any) Alignment {
+	return Alignment{mode: alignLongest, fills: fills}
+}
+
+// AlignBroadcast repeats the single element of the slices of length 1 to
+// match the length of the other slices, as NumPy broadcasting does. All
+// slices must either have length 1 or share the same length.
+func AlignBroadcast() Alignment {
+	return Alignment{mode: alignBroadcast}
+}
+
+// functionalArgs holds the slices of a functional map once lined up.
+type functionalArgs struct {
+	n     int
+	xss   []reflect.Value
+	fills []reflect.Value
+	align Alignment
+}
+
+// at returns the i-th argument taken from the j-th slice.
+func (a functionalArgs) at(j int, i int) reflect.Value {
+	xs := a.xss[j]
+	switch {
+	case a.align.mode == alignBroadcast && xs.Len() == 1:
+		return xs.Index(0)
+	case i >= xs.Len():
+		return a.fills[j]
+	default:
+		return xs.Index(i)
+	}
+}
+
+// functionalInputs checks that every xss[i] is a slice of the type of the
+// parameter skip+i of fnType, and lines them up according to align.
+func functionalInputs(fnType reflect.Type, skip int, xss []any, align Alignment) (functionalArgs, error) {
+	args := functionalArgs{
+		xss:   make([]reflect.Value, len(xss)),
+		fills: make([]reflect.Value, len(xss)),
+		align: align,
+	}
+	for i := range xss {
+		xsReflect := reflect.ValueOf(xss[i])
+		if xsReflect.Kind() != reflect.Slice {
+			return args, fmt.Errorf("xss[%d]: input parameter is not a slice", i)
+		}
+
+		elem := fnType.In(skip + i)
+		if xsReflect.Type().Elem() != elem {
+			return args, fmt.Errorf("xss[%d]: input parameter type mismatch", i)
+		}
+
+		args.xss[i] = xsReflect
+		args.fills[i] = reflect.New(elem).Elem()
+		if i < len(align.fills) && align.fills[i] != nil {
+			fill := reflect.ValueOf(align.fills[i])
+			if !fill.Type().AssignableTo(elem) {
+				return args, fmt.Errorf("xss[%d]: fill value type mismatch", i)
+			}
+			args.fills[i].Set(fill)
+		}
+	}
+
+	for i, xs := range args.xss {
+		n := xs.Len()
+		switch {
+		case i == 0:
+			args.n = n
+		case align.mode == alignShortest:
+			args.n = min(args.n, n)
+		case align.mode == alignLongest:
+			args.n = max(args.n, n)
+		case align.mode == alignBroadcast && (args.n == 1 || n == 1):
+			if args.n == 1 {
+				args.n = n
+			}
+		case n != args.n:
+			if align.mode == alignBroadcast {
+				return args, fmt.Errorf("xss[%d]: input parameter slice length mismatch, cannot broadcast %d to %d", i, n, args.n)
+			}
+			return args, fmt.Errorf("xss[%d]: input parameter slice length mismatch", i)
+		}
+	}
+	return args, nil
+}
+
+// zipWithError merges the errors of pulling one element from each of the
+// streams zipped by StreamZipWith and its siblings. Any failure is
+// reported as is, and the zipped stream is exhausted only if all of the
+// streams are.
+func zipWithError(errs ...error) error {
+	exhausted := 0
+	for _, err := range errs {
+		if errors.Is(err, ErrStreamExhausted) {
+			exhausted++
+		} else if err != nil {
+			return err
+		}
+	}
+	switch exhausted {
+	case 0:
+		return nil
+	case len(errs):
+		return ErrStreamExhausted
+	default:
+		return errors.New("input parameter stream length mismatch")
+	}
+}
@@ -0,0 +1,49 @@
+package vino_test
+
+import (
+	"testing"
+
+	. "github.com/humbornjo/vino"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFunctionalMapAligned(t *testing.T) {
+	add := func(a int, b int) int { return a + b }
+
+	tests := []struct {
+		name  string
+		align Alignment
+		xs    []int
+		ys    []int
+		want  []int
+		err   string
+	}{
+		{"strict", AlignStrict(), []int{1, 2}, []int{10, 20}, []int{11, 22}, ""},
+		{"strict mismatch", AlignStrict(), []int{1, 2}, []int{10}, nil, "xss[1]: input parameter slice length mismatch"},
+		{"shortest", AlignShortest(), []int{1, 2, 3}, []int{10, 20}, []int{11, 22}, ""},
+		{"longest zero", AlignLongest(), []int{1}, []int{10, 20, 30}, []int{11, 20, 30}, ""},
+		{"longest fill", AlignLongest(nil, 100), []int{1, 2, 3}, []int{10}, []int{11, 102, 103}, ""},
+		{"longest bad fill", AlignLongest("x"), []int{1}, []int{10}, nil, "xss[0]: fill value type mismatch"},
+		{"broadcast", AlignBroadcast(), []int{1, 2, 3}, []int{10}, []int{11, 12, 13}, ""},
+		{"broadcast left", AlignBroadcast(), []int{1}, []int{10, 20}, []int{11, 21}, ""},
+		{"broadcast empty", AlignBroadcast(), []int{1}, []int{}, []int{}, ""},
+		{"broadcast mismatch", AlignBroadcast(), []int{1, 2, 3}, []int{10, 20}, nil, "xss[1]: input parameter slice length mismatch, cannot broadcast 2 to 3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FunctionalMapAligned[int](tt.align, add, tt.xs, tt.ys)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := FunctionalMap[int](add, []int{1}, []string{"a"})
+	assert.EqualError(t, err, "xss[1]: input parameter type mismatch")
+	_, err = FunctionalMap[int](add, []int{1}, 2)
+	assert.EqualError(t, err, "xss[1]: input parameter is not a slice")
+}
@@ -0,0 +1,151 @@
+package vino
+
+import "errors"
+
+type rangeStream[N Number] struct {
+	cur  N
+	stop N
+	step N
+}
+
+func (s *rangeStream[N]) Next() (N, error) {
+	if s.step > 0 && s.cur >= s.stop || s.step < 0 && s.cur <= s.stop || s.step == 0 {
+		return 0, ErrStreamExhausted
+	}
+	x := s.cur
+	next := s.cur + s.step
+	if s.step > 0 && next < s.cur || s.step < 0 && next > s.cur {
+		// The next value overflowed, so x was the last one.
+		s.step = 0
+	}
+	s.cur = next
+	return x, nil
+}
+
+// StreamRange returns a stream over the numbers from start up to, but
+// not including, stop, moving by step, just like range in Python. The
+// step defaults to 1 and can be negative to count down. A zero step
+// yields nothing.
+//
+// Example:
+//
+//	StreamRange(0, 5)         // 0, 1, 2, 3, 4
+//	StreamRange(10, 0, -3)    // 10, 7, 4, 1
+//	StreamRange(0.0, 1, 0.25) // 0, 0.25, 0.5, 0.75
+func StreamRange[N Number](start N, stop N, step ...N) Stream[N] {
+	s := &rangeStream[N]{cur: start, stop: stop, step: 1}
+	if len(step) > 0 {
+		s.step = step[0]
+	}
+	return s
+}
+
+type iterateStream[T any] struct {
+	x  T
+	f  func(T) T
+	ok bool
+}
+
+func (s *iterateStream[T]) Next() (T, error) {
+	if s.ok {
+		s.x = s.f(s.x)
+	}
+	s.ok = true
+	return s.x, nil
+}
+
+// StreamIterate returns the endless stream seed, f(seed), f(f(seed)), and
+// so on. f is only called when the next element is pulled.
+func StreamIterate[T any](seed T, f func(T) T) Stream[T] {
+	return &iterateStream[T]{x: seed, f: f}
+}
+
+type generateStream[T any] struct {
+	f func() T
+}
+
+func (s *generateStream[T]) Next() (T, error) {
+	return s.f(), nil
+}
+
+// StreamGenerate returns the endless stream of the values returned by
+// successive calls to f.
+func StreamGenerate[T any](f func() T) Stream[T] {
+	return &generateStream[T]{f: f}
+}
+
+type cycleStream[T any] struct {
+	s      Stream[T]
+	repeat int
+	seen   []T
+}
+
+func (s *cycleStream[T]) Next() (T, error) {
+	x, err := s.s.Next()
+	if err == nil {
+		if s.seen != nil {
+			s.seen = append(s.seen, x)
+		}
+		return x, nil
+	}
+	if !errors.Is(err, ErrStreamExhausted) || s.seen == nil {
+		return *new(T), err
+	}
+
+	// The first pass is over, replay it from now on.
+	StreamClose(s.s)
+	xs, repeat := s.seen, s.repeat
+	if repeat == 0 {
+		xs = nil
+	} else if repeat > 0 {
+		repeat--
+	}
+	s.s, s.seen = &repeatedStream[T]{xs: xs, repeat: repeat, init: repeat}, nil
+	return s.s.Next()
+}
+
+func (s *cycleStream[T]) Close() error {
+	return StreamClose(s.s)
+}
+
+// StreamCycle returns a stream that yields the elements of s, then starts
+// over repeat more times, with the same repeat semantics as
+// NewRepeatedStream: zero means a single pass and a negative repeat
+// cycles forever. The elements of the first pass are kept in memory to be
+// replayed, and s is closed once it is exhausted.
+func StreamCycle[T any](s Stream[T], repeat int) Stream[T] {
+	return &cycleStream[T]{s: s, repeat: repeat, seen: make([]T, 0)}
+}
+
+type unfoldStream[S any, T any] struct {
+	state S
+	step  func(S) (T, S, bool)
+	done  bool
+}
+
+func (s *unfoldStream[S, T]) Next() (T, error) {
+	if s.done {
+		return *new(T), ErrStreamExhausted
+	}
+	x, state, ok := s.step(s.state)
+	if !ok {
+		s.done = true
+		return *new(T), ErrStreamExhausted
+	}
+	s.state = state
+	return x, nil
+}
+
+// StreamUnfold returns a stream built by repeatedly applying step to a
+// state, starting from state. Each call to step yields an element along
+// with the next state, and the stream ends as soon as step reports false.
+//
+// Example:
+//
+//	// 1, 1, 2, 3, 5, 8, ...
+//	fib := StreamUnfold([2]int{1, 1}, func(s [2]int) (int, [2]int, bool) {
+//	    return s[0], [2]int{s[1], s[0] + s[1]}, true
+//	})
+func StreamUnfold[S any, T any](state S, step func(S) (T, S, bool)) Stream[T] {
+	return &unfoldStream[S, T]{state: state, step: step}
+}
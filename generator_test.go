@@ -0,0 +1,59 @@
+package vino_test
+
+import (
+	"math"
+	"testing"
+
+	. "github.com/humbornjo/vino"
+	"github.com/stretchr/testify/assert"
+)
+
+func collect[T any](t *testing.T, s Stream[T]) []T {
+	xs, err := StreamCollect(s)
+	assert.NoError(t, err)
+	return xs
+}
+
+func TestStreamRange(t *testing.T) {
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, collect(t, StreamRange(0, 5)))
+	assert.Equal(t, []int{10, 7, 4, 1}, collect(t, StreamRange(10, 0, -3)))
+	assert.Equal(t, []int{}, collect(t, StreamRange(5, 0)))
+	assert.Equal(t, []int{}, collect(t, StreamRange(0, 5, 0)))
+	assert.Equal(t, []float64{0, 0.25, 0.5, 0.75}, collect(t, StreamRange(0.0, 1, 0.25)))
+	assert.Equal(t, []uint8{250}, collect(t, StreamRange[uint8](250, math.MaxUint8, 5)))
+	assert.Equal(t, []uint8{253}, collect(t, StreamRange[uint8](253, math.MaxUint8, 5)))
+}
+
+func TestStreamIterateGenerate(t *testing.T) {
+	double := func(x int) int { return x * 2 }
+	assert.Equal(t, []int{1, 2, 4, 8}, collect(t, StreamTake(StreamIterate(1, double), 4)))
+
+	n := 0
+	counter := func() int { n++; return n }
+	assert.Equal(t, []int{1, 2, 3}, collect(t, StreamTake(StreamGenerate(counter), 3)))
+	assert.Equal(t, 3, n)
+}
+
+func TestStreamCycle(t *testing.T) {
+	assert.Equal(t, []int{1, 2}, collect(t, StreamCycle(streamOf(1, 2), 0)))
+	assert.Equal(t, []int{1, 2, 1, 2, 1, 2}, collect(t, StreamCycle(streamOf(1, 2), 2)))
+	assert.Equal(t, []int{1, 2, 1, 2, 1}, collect(t, StreamTake(StreamCycle(streamOf(1, 2), -1), 5)))
+	assert.Equal(t, []int{}, collect(t, StreamCycle(streamOf[int](), -1)))
+
+	s := StreamCycle(streamOf(1), 0)
+	assert.Equal(t, []int{1}, collect(t, s))
+	_, err := s.Next()
+	assert.ErrorIs(t, err, ErrStreamExhausted)
+}
+
+func TestStreamUnfold(t *testing.T) {
+	fib := StreamUnfold([2]int{1, 1}, func(s [2]int) (int, [2]int, bool) {
+		return s[0], [2]int{s[1], s[0] + s[1]}, true
+	})
+	assert.Equal(t, []int{1, 1, 2, 3, 5, 8}, collect(t, StreamTake(fib, 6)))
+
+	digits := StreamUnfold(1234, func(n int) (int, int, bool) {
+		return n % 10, n / 10, n > 0
+	})
+	assert.Equal(t, []int{4, 3, 2, 1}, collect(t, digits))
+}
@@ -0,0 +1,158 @@
+package vino
+
+import (
+	"errors"
+	"iter"
+)
+
+// ------------------------------------------------------------------------
+//  Stream -> iter.Seq
+// ------------------------------------------------------------------------
+
+// StreamSeq returns an iterator that yields the elements of s until its
+// Next returns an error. Once the range loop ends, whether s is drained or
+// the loop breaks early, s is closed, so the iterator is single-use. The
+// error that ended s, as well as the one from closing it, is dropped; use
+// StreamSeqErr to learn about failures.
+//
+// Example:
+//
+//	for x := range StreamSeq(s) {
+//	    // do things with x
+//	}
+func StreamSeq[T any](s Stream[T]) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		defer StreamClose(s)
+		for {
+			x, err := s.Next()
+			if err != nil {
+				return
+			}
+			if !yield(x) {
+				return
+			}
+		}
+	}
+}
+
+// StreamSeqErr is like StreamSeq but also reports how the stream ended.
+// Every element is yielded with a nil error. If s fails with anything
+// other than ErrStreamExhausted, the failure is yielded last along with
+// a zero value. Like StreamSeq, s is closed once the range loop ends, and
+// a failure to close it is yielded last.
+func StreamSeqErr[T any](s Stream[T]) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		stopped := false
+		defer func() {
+			if err := StreamClose(s); err != nil && !stopped {
+				yield(*new(T), err)
+			}
+		}()
+		for {
+			x, err := s.Next()
+			if errors.Is(err, ErrStreamExhausted) {
+				return
+			}
+			if !yield(x, err) {
+				stopped = true
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+// StreamSeq2 returns an iterator that unpacks the pairs yielded by s into
+// key-value form, which is the inverse of Seq2Stream.
+func StreamSeq2[K any, V any](s Stream[Pair[K, V]]) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for p := range StreamSeq(s) {
+			if !yield(p.Left, p.Right) {
+				return
+			}
+		}
+	}
+}
+
+// ------------------------------------------------------------------------
+//  iter.Seq -> Stream
+// ------------------------------------------------------------------------
+
+type pullStream[T any] struct {
+	next func() (T, bool)
+	stop func()
+}
+
+func (s *pullStream[T]) Next() (T, error) {
+	x, ok := s.next()
+	if !ok {
+		s.stop()
+		return *new(T), ErrStreamExhausted
+	}
+	return x, nil
+}
+
+func (s *pullStream[T]) Close() error {
+	s.stop()
+	return nil
+}
+
+// SeqStream converts the push iterator seq into a Stream by means of
+// iter.Pull. Just like iter.Pull, it returns a stop function alongside the
+// stream. The stop function is called automatically once seq is drained,
+// but callers that abandon the stream early must call it themselves so
+// that the goroutine backing seq can be released. Calling stop more than
+// once is harmless, and closing the stream calls it as well.
+func SeqStream[T any](seq iter.Seq[T]) (Stream[T], func()) {
+	next, stop := iter.Pull(seq)
+	return &pullStream[T]{next: next, stop: stop}, stop
+}
+
+// Seq2Stream is like SeqStream but converts a key-value iterator into a
+// Stream of pairs.
+func Seq2Stream[K any, V any](seq iter.Seq2[K, V]) (Stream[Pair[K, V]], func()) {
+	return SeqStream(func(yield func(Pair[K, V]) bool) {
+		for k, v := range seq {
+			if !yield(Pair[K, V]{Left: k, Right: v}) {
+				return
+			}
+		}
+	})
+}
+
+// ------------------------------------------------------------------------
+//  Slice Iterators
+// ------------------------------------------------------------------------
+
+// SliceSeq returns an iterator over the index and element pairs of s. It
+// is the range-over-func counterpart of SliceIter, and breaking out of the
+// loop early replaces the false return of SliceWalk.
+func SliceSeq[T any](s []T) iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		for i := range s {
+			if !yield(i, s[i]) {
+				return
+			}
+		}
+	}
+}
+
+// SliceUniqueSeq returns an iterator over the unique elements of s. Unlike
+// SliceUnique, elements are yielded lazily in the order of their first
+// occurrence.
+func SliceUniqueSeq[T comparable](s []T) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		seen := make(map[T]struct{})
+		for _, x := range s {
+			if _, ok := seen[x]; ok {
+				continue
+			}
+			seen[x] = struct{}{}
+			if !yield(x) {
+				return
+			}
+		}
+	}
+}
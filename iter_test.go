@@ -0,0 +1,95 @@
+package vino_test
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+
+	. "github.com/humbornjo/vino"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamSeq(t *testing.T) {
+	assert.Equal(t, []int{1, 2, 3}, slices.Collect(StreamSeq(streamOf(1, 2, 3))))
+
+	// Breaking early, like draining, closes the stream.
+	s := &failingStream{n: 4, err: ErrStreamExhausted}
+	for x := range StreamSeq[int](s) {
+		if x == 2 {
+			break
+		}
+	}
+	assert.True(t, s.closed)
+	assert.Equal(t, 2, s.n)
+	s = &failingStream{n: 2, err: ErrStreamExhausted}
+	assert.Equal(t, []int{1, 0}, slices.Collect(StreamSeq[int](s)))
+	assert.True(t, s.closed)
+
+	pairs := streamOf(Pair[string, int]{"a", 1}, Pair[string, int]{"b", 2})
+	assert.Equal(t, map[string]int{"a": 1, "b": 2}, maps.Collect(StreamSeq2(pairs)))
+
+	errBoom := errors.New("boom")
+	xs, errs := []int{}, []error{}
+	for x, err := range StreamSeqErr[int](&failingStream{n: 2, err: errBoom}) {
+		xs, errs = append(xs, x), append(errs, err)
+	}
+	assert.Equal(t, []int{1, 0, 0}, xs)
+	assert.Equal(t, []error{nil, nil, errBoom}, errs)
+
+	s = &failingStream{n: 3, err: errBoom}
+	for range StreamSeqErr[int](s) {
+		break
+	}
+	assert.True(t, s.closed)
+}
+
+func TestSeqStream(t *testing.T) {
+	s, stop := SeqStream(slices.Values([]int{1, 2, 3}))
+	defer stop()
+	xs, err := StreamCollect(s)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, xs)
+
+	// Draining the stream stops the iterator on its own.
+	_, err = s.Next()
+	assert.ErrorIs(t, err, ErrStreamExhausted)
+
+	released := false
+	seq := func(yield func(int) bool) {
+		defer func() { released = true }()
+		for i := 0; ; i++ {
+			if !yield(i) {
+				return
+			}
+		}
+	}
+	s, stop = SeqStream(seq)
+	xs, err = StreamCollect(StreamTake(s, 3))
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 1, 2}, xs)
+	assert.False(t, released)
+	assert.NoError(t, StreamClose(s))
+	assert.True(t, released)
+	stop()
+
+	s2, stop2 := Seq2Stream(slices.All([]string{"a", "b"}))
+	defer stop2()
+	pairs, err := StreamCollect(s2)
+	assert.NoError(t, err)
+	assert.Equal(t, []Pair[int, string]{{0, "a"}, {1, "b"}}, pairs)
+}
+
+func TestSliceSeq(t *testing.T) {
+	idx := []int{}
+	for i, x := range SliceSeq([]string{"a", "b", "c"}) {
+		if x == "c" {
+			break
+		}
+		idx = append(idx, i)
+	}
+	assert.Equal(t, []int{0, 1}, idx)
+
+	unique := slices.Collect(SliceUniqueSeq([]int{3, 1, 3, 2, 1}))
+	assert.Equal(t, []int{3, 1, 2}, unique)
+}
@@ -0,0 +1,325 @@
+package vino
+
+import "errors"
+
+// JoinKind selects which unmatched rows a join keeps.
+type JoinKind int
+
+const (
+	// InnerJoin keeps only the rows that have a match on both sides.
+	InnerJoin JoinKind = iota
+	// LeftJoin also keeps the left rows that have no match on the right.
+	LeftJoin
+	// FullOuterJoin also keeps the rows of either side that have no match
+	// on the other.
+	FullOuterJoin
+)
+
+// ErrJoinMemoryCap is returned by a hash join whose build side holds more
+// rows than it is allowed to keep in memory.
+var ErrJoinMemoryCap = errors.New("hash join exceeds memory cap")
+
+// JoinRow is a row produced by a join. The side of an unmatched row that
+// has no counterpart is left to its zero value, and HasLeft or HasRight
+// tells it apart from a genuine zero value.
+type JoinRow[L any, R any] struct {
+	Left     L
+	Right    R
+	HasLeft  bool
+	HasRight bool
+}
+
+// LeftOption returns the left side of the row as an option, which is None
+// if the row has no left side.
+func (r JoinRow[L, R]) LeftOption() option {
+	if !r.HasLeft {
+		return None
+	}
+	return Option(&r.Left)
+}
+
+// RightOption returns the right side of the row as an option, which is
+// None if the row has no right side.
+func (r JoinRow[L, R]) RightOption() option {
+	if !r.HasRight {
+		return None
+	}
+	return Option(&r.Right)
+}
+
+type mergeJoinStream[K any, L any, R any] struct {
+	kind  JoinKind
+	left  Stream[L]
+	right Stream[R]
+	lidx  func(L) K
+	ridx  func(R) K
+	cmp   func(K, K) int
+
+	l        L
+	r        R
+	lok, rok bool
+	init     bool
+	key      K
+	group    []R
+	out      []JoinRow[L, R]
+	err      error
+}
+
+func (s *mergeJoinStream[K, L, R]) nextLeft() error {
+	x, err := s.left.Next()
+	if errors.Is(err, ErrStreamExhausted) {
+		s.lok = false
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	s.l, s.lok = x, true
+	return nil
+}
+
+func (s *mergeJoinStream[K, L, R]) nextRight() error {
+	x, err := s.right.Next()
+	if errors.Is(err, ErrStreamExhausted) {
+		s.rok = false
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	s.r, s.rok = x, true
+	return nil
+}
+
+// step moves the join forward by one left or right row, queueing the
+// rows it produces.
+func (s *mergeJoinStream[K, L, R]) step() error {
+	if !s.init {
+		s.init = true
+		if err := s.nextLeft(); err != nil {
+			return err
+		}
+		if err := s.nextRight(); err != nil {
+			return err
+		}
+	}
+
+	// Left rows sharing the key of the last right group are matched
+	// against the whole group.
+	if s.group != nil {
+		if s.lok && s.cmp(s.lidx(s.l), s.key) == 0 {
+			for _, r := range s.group {
+				s.out = append(s.out, JoinRow[L, R]{Left: s.l, Right: r, HasLeft: true, HasRight: true})
+			}
+			return s.nextLeft()
+		}
+		s.group = nil
+	}
+
+	cmp := 0
+	switch {
+	case !s.lok && !s.rok:
+		return ErrStreamExhausted
+	case !s.rok:
+		cmp = -1
+	case !s.lok:
+		cmp = 1
+	default:
+		cmp = s.cmp(s.lidx(s.l), s.ridx(s.r))
+	}
+
+	switch {
+	case cmp < 0:
+		if s.kind == InnerJoin && !s.rok {
+			return ErrStreamExhausted
+		}
+		if s.kind != InnerJoin {
+			s.out = append(s.out, JoinRow[L, R]{Left: s.l, HasLeft: true})
+		}
+		return s.nextLeft()
+	case cmp > 0:
+		if s.kind != FullOuterJoin && !s.lok {
+			return ErrStreamExhausted
+		}
+		if s.kind == FullOuterJoin {
+			s.out = append(s.out, JoinRow[L, R]{Right: s.r, HasRight: true})
+		}
+		return s.nextRight()
+	}
+
+	// Gather every right row sharing the key, the left rows are then
+	// matched against them one at a time.
+	s.key = s.ridx(s.r)
+	s.group = []R{s.r}
+	for {
+		if err := s.nextRight(); err != nil {
+			return err
+		}
+		if !s.rok || s.cmp(s.ridx(s.r), s.key) != 0 {
+			return nil
+		}
+		s.group = append(s.group, s.r)
+	}
+}
+
+func (s *mergeJoinStream[K, L, R]) Next() (JoinRow[L, R], error) {
+	for len(s.out) == 0 {
+		if s.err != nil {
+			return JoinRow[L, R]{}, s.err
+		}
+		s.err = s.step()
+	}
+	row := s.out[0]
+	s.out = s.out[1:]
+	return row, nil
+}
+
+func (s *mergeJoinStream[K, L, R]) Close() error {
+	return errors.Join(StreamClose(s.left), StreamClose(s.right))
+}
+
+// StreamMergeJoin lazily joins two streams that are both sorted by key,
+// the way MergeImpl merges sorted sequences: lidx and ridx extract the key
+// of a left and a right row and cmp orders the keys. Only the right rows
+// sharing the current key are held in memory. Rows come out in key order,
+// and rows with equal keys come out as the cross product of both sides in
+// their original order. A failure of either stream ends the join with
+// that failure. Closing the join closes both streams.
+//
+// Example:
+//
+//	// Pair every order with its shipments, keeping unshipped orders.
+//	StreamMergeJoin(LeftJoin, orders, shipments,
+//	    func(o Order) int { return o.ID },
+//	    func(s Shipment) int { return s.OrderID },
+//	    cmp.Compare[int])
+func StreamMergeJoin[K any, L any, R any](
+	kind JoinKind,
+	left Stream[L],
+	right Stream[R],
+	lidx func(L) K,
+	ridx func(R) K,
+	cmp func(K, K) int,
+) Stream[JoinRow[L, R]] {
+	return &mergeJoinStream[K, L, R]{kind: kind, left: left, right: right, lidx: lidx, ridx: ridx, cmp: cmp}
+}
+
+type hashJoinRow[R any] struct {
+	x       R
+	matched bool
+}
+
+type hashJoinStream[K comparable, L any, R any] struct {
+	kind    JoinKind
+	left    Stream[L]
+	right   Stream[R]
+	lkey    func(L) K
+	rkey    func(R) K
+	maxRows int
+
+	rows  []hashJoinRow[R]
+	index map[K][]int
+	init  bool
+	probe bool
+	out   []JoinRow[L, R]
+	err   error
+}
+
+// build loads the right stream into memory.
+func (s *hashJoinStream[K, L, R]) build() error {
+	s.index = make(map[K][]int)
+	for {
+		x, err := s.right.Next()
+		if errors.Is(err, ErrStreamExhausted) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if s.maxRows > 0 && len(s.rows) >= s.maxRows {
+			return ErrJoinMemoryCap
+		}
+		k := s.rkey(x)
+		s.index[k] = append(s.index[k], len(s.rows))
+		s.rows = append(s.rows, hashJoinRow[R]{x: x})
+	}
+}
+
+// step probes the in-memory right rows with the next left row, then
+// queues the unmatched right rows once the left stream is exhausted.
+func (s *hashJoinStream[K, L, R]) step() error {
+	if !s.init {
+		s.init, s.probe = true, true
+		if err := s.build(); err != nil {
+			return err
+		}
+	}
+	if !s.probe {
+		return ErrStreamExhausted
+	}
+
+	l, err := s.left.Next()
+	if errors.Is(err, ErrStreamExhausted) {
+		s.probe = false
+		if s.kind == FullOuterJoin {
+			for _, r := range s.rows {
+				if !r.matched {
+					s.out = append(s.out, JoinRow[L, R]{Right: r.x, HasRight: true})
+				}
+			}
+		}
+		s.rows, s.index = nil, nil
+		return ErrStreamExhausted
+	}
+	if err != nil {
+		return err
+	}
+
+	matches := s.index[s.lkey(l)]
+	if len(matches) == 0 && s.kind != InnerJoin {
+		s.out = append(s.out, JoinRow[L, R]{Left: l, HasLeft: true})
+	}
+	for _, i := range matches {
+		s.rows[i].matched = true
+		s.out = append(s.out, JoinRow[L, R]{Left: l, Right: s.rows[i].x, HasLeft: true, HasRight: true})
+	}
+	return nil
+}
+
+func (s *hashJoinStream[K, L, R]) Next() (JoinRow[L, R], error) {
+	for len(s.out) == 0 {
+		if s.err != nil {
+			return JoinRow[L, R]{}, s.err
+		}
+		s.err = s.step()
+	}
+	row := s.out[0]
+	s.out = s.out[1:]
+	return row, nil
+}
+
+func (s *hashJoinStream[K, L, R]) Close() error {
+	return errors.Join(StreamClose(s.left), StreamClose(s.right))
+}
+
+// StreamHashJoin joins two streams that need not be sorted. The right
+// stream is loaded into a hash table keyed by rkey on the first call to
+// Next, then the left stream is streamed through it, so the right stream
+// should be the smaller one. Rows come out in the order of the left
+// stream, matches in the order of the right stream, and for a
+// FullOuterJoin the unmatched right rows come out last.
+//
+// maxRows caps the number of right rows held in memory; the join fails
+// with ErrJoinMemoryCap when the right stream is longer than that. A
+// non-positive maxRows means no cap. Closing the join closes both
+// streams.
+func StreamHashJoin[K comparable, L any, R any](
+	kind JoinKind,
+	left Stream[L],
+	right Stream[R],
+	lkey func(L) K,
+	rkey func(R) K,
+	maxRows int,
+) Stream[JoinRow[L, R]] {
+	return &hashJoinStream[K, L, R]{kind: kind, left: left, right: right, lkey: lkey, rkey: rkey, maxRows: maxRows}
+}
@@ -0,0 +1,122 @@
+package vino_test
+
+import (
+	"cmp"
+	"errors"
+	"testing"
+
+	. "github.com/humbornjo/vino"
+	"github.com/stretchr/testify/assert"
+)
+
+type order struct {
+	id   int
+	item string
+}
+
+type shipment struct {
+	order   int
+	carrier string
+}
+
+func joinRows(t *testing.T, s Stream[JoinRow[order, shipment]]) []string {
+	t.Helper()
+	rows, err := StreamCollect(s)
+	assert.NoError(t, err)
+	ret := make([]string, 0, len(rows))
+	for _, row := range rows {
+		l, r := "-", "-"
+		if row.HasLeft {
+			l = row.Left.item
+		}
+		if row.HasRight {
+			r = row.Right.carrier
+		}
+		ret = append(ret, l+":"+r)
+	}
+	return ret
+}
+
+func TestStreamMergeJoin(t *testing.T) {
+	orders := []order{{1, "tea"}, {2, "cup"}, {2, "pot"}, {4, "jar"}}
+	shipments := []shipment{{0, "dhl"}, {2, "ups"}, {2, "fedex"}, {3, "usps"}, {4, "dhl"}}
+
+	tests := []struct {
+		name string
+		kind JoinKind
+		want []string
+	}{
+		{"inner", InnerJoin, []string{"cup:ups", "cup:fedex", "pot:ups", "pot:fedex", "jar:dhl"}},
+		{"left", LeftJoin, []string{"tea:-", "cup:ups", "cup:fedex", "pot:ups", "pot:fedex", "jar:dhl"}},
+		{"full outer", FullOuterJoin, []string{"-:dhl", "tea:-", "cup:ups", "cup:fedex", "pot:ups", "pot:fedex", "-:usps", "jar:dhl"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := StreamMergeJoin(tt.kind, streamOf(orders...), streamOf(shipments...),
+				func(o order) int { return o.id },
+				func(s shipment) int { return s.order },
+				cmp.Compare[int])
+			assert.Equal(t, tt.want, joinRows(t, s))
+		})
+	}
+}
+
+func TestStreamHashJoin(t *testing.T) {
+	orders := []order{{4, "jar"}, {2, "cup"}, {1, "tea"}, {2, "pot"}}
+	shipments := []shipment{{2, "ups"}, {3, "usps"}, {4, "dhl"}, {2, "fedex"}}
+	lkey := func(o order) int { return o.id }
+	rkey := func(s shipment) int { return s.order }
+
+	tests := []struct {
+		name string
+		kind JoinKind
+		want []string
+	}{
+		{"inner", InnerJoin, []string{"jar:dhl", "cup:ups", "cup:fedex", "pot:ups", "pot:fedex"}},
+		{"left", LeftJoin, []string{"jar:dhl", "cup:ups", "cup:fedex", "tea:-", "pot:ups", "pot:fedex"}},
+		{"full outer", FullOuterJoin, []string{"jar:dhl", "cup:ups", "cup:fedex", "tea:-", "pot:ups", "pot:fedex", "-:usps"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := StreamHashJoin(tt.kind, streamOf(orders...), streamOf(shipments...), lkey, rkey, 4)
+			assert.Equal(t, tt.want, joinRows(t, s))
+		})
+	}
+
+	s := StreamHashJoin(InnerJoin, streamOf(orders...), streamOf(shipments...), lkey, rkey, 3)
+	_, err := s.Next()
+	assert.ErrorIs(t, err, ErrJoinMemoryCap)
+}
+
+func TestStreamJoin_Failure(t *testing.T) {
+	errBoom := errors.New("boom")
+	id := func(x int) int { return x }
+
+	s := StreamMergeJoin[int, int, int](InnerJoin, streamOf(0, 1, 2), &failingStream{n: 1, err: errBoom}, id, id, cmp.Compare[int])
+	got, err := StreamCollect(s)
+	assert.ErrorIs(t, err, errBoom)
+	assert.Equal(t, []JoinRow[int, int]{}, got)
+
+	closed := &failingStream{n: 0, err: ErrStreamExhausted}
+	s = StreamHashJoin[int, int, int](LeftJoin, streamOf(7), closed, id, id, 0)
+	got, err = StreamCollect(s)
+	assert.NoError(t, err)
+	assert.Equal(t, []JoinRow[int, int]{{Left: 7, HasLeft: true}}, got)
+	assert.NoError(t, StreamClose(s))
+	assert.True(t, closed.closed)
+}
+
+func TestJoinRow_Option(t *testing.T) {
+	row := JoinRow[int, string]{Left: 1, HasLeft: true}
+
+	v := new(int)
+	switch o, Some := Match[int](row.LeftOption()); o {
+	case None:
+		t.Fatal("left side should be some")
+	case Some(v):
+		assert.Equal(t, 1, *v)
+	}
+	assert.Equal(t, None, row.RightOption())
+}
@@ -0,0 +1,195 @@
+// Code generated by genmap; DO NOT EDIT.
+
+package vino
+
+import "errors"
+
+// Map is the type-safe counterpart of FunctionalMap for a single slice: it
+// returns the results of fn applied to every element of xs1.
+func Map[T1 any, U any](fn func(T1) U, xs1 []T1) []U {
+	ret := make([]U, len(xs1))
+	for i := range xs1 {
+		ret[i] = fn(xs1[i])
+	}
+	return ret
+}
+
+// Map2 is the type-safe counterpart of FunctionalMap for 2 slices: it
+// returns the results of fn applied to the corresponding elements of
+// xs1, xs2. It fails if the slices differ in length.
+func Map2[T1 any, T2 any, U any](fn func(T1, T2) U, xs1 []T1, xs2 []T2) ([]U, error) {
+	n := len(xs1)
+	if len(xs2) != n {
+		return nil, errors.New("input parameter slice length mismatch")
+	}
+	ret := make([]U, n)
+	for i := range n {
+		ret[i] = fn(xs1[i], xs2[i])
+	}
+	return ret, nil
+}
+
+// Map3 is the type-safe counterpart of FunctionalMap for 3 slices: it
+// returns the results of fn applied to the corresponding elements of
+// xs1, xs2, xs3. It fails if the slices differ in length.
+func Map3[T1 any, T2 any, T3 any, U any](fn func(T1, T2, T3) U, xs1 []T1, xs2 []T2, xs3 []T3) ([]U, error) {
+	n := len(xs1)
+	if len(xs2) != n || len(xs3) != n {
+		return nil, errors.New("input parameter slice length mismatch")
+	}
+	ret := make([]U, n)
+	for i := range n {
+		ret[i] = fn(xs1[i], xs2[i], xs3[i])
+	}
+	return ret, nil
+}
+
+// Map4 is the type-safe counterpart of FunctionalMap for 4 slices: it
+// returns the results of fn applied to the corresponding elements of
+// xs1, xs2, xs3, xs4. It fails if the slices differ in length.
+func Map4[T1 any, T2 any, T3 any, T4 any, U any](fn func(T1, T2, T3, T4) U, xs1 []T1, xs2 []T2, xs3 []T3, xs4 []T4) ([]U, error) {
+	n := len(xs1)
+	if len(xs2) != n || len(xs3) != n || len(xs4) != n {
+		return nil, errors.New("input parameter slice length mismatch")
+	}
+	ret := make([]U, n)
+	for i := range n {
+		ret[i] = fn(xs1[i], xs2[i], xs3[i], xs4[i])
+	}
+	return ret, nil
+}
+
+// Map5 is the type-safe counterpart of FunctionalMap for 5 slices: it
+// returns the results of fn applied to the corresponding elements of
+// xs1, xs2, xs3, xs4, xs5. It fails if the slices differ in length.
+func Map5[T1 any, T2 any, T3 any, T4 any, T5 any, U any](fn func(T1, T2, T3, T4, T5) U, xs1 []T1, xs2 []T2, xs3 []T3, xs4 []T4, xs5 []T5) ([]U, error) {
+	n := len(xs1)
+	if len(xs2) != n || len(xs3) != n || len(xs4) != n || len(xs5) != n {
+		return nil, errors.New("input parameter slice length mismatch")
+	}
+	ret := make([]U, n)
+	for i := range n {
+		ret[i] = fn(xs1[i], xs2[i], xs3[i], xs4[i], xs5[i])
+	}
+	return ret, nil
+}
+
+type zipWith2Stream[T1 any, T2 any, U any] struct {
+	s1 Stream[T1]
+	s2 Stream[T2]
+	fn func(T1, T2) U
+}
+
+func (s *zipWith2Stream[T1, T2, U]) Next() (U, error) {
+	x1, err1 := s.s1.Next()
+	x2, err2 := s.s2.Next()
+	if err := zipWithError(err1, err2); err != nil {
+		return *new(U), err
+	}
+	return s.fn(x1, x2), nil
+}
+
+func (s *zipWith2Stream[T1, T2, U]) Close() error {
+	return errors.Join(StreamClose(s.s1), StreamClose(s.s2))
+}
+
+// StreamZipWith is the stream counterpart of Map2: it returns a stream of
+// the results of fn applied to the corresponding elements of
+// s1, s2. The streams are expected to end together, and the
+// resulting stream fails if they do not. Closing it closes all of them.
+func StreamZipWith[T1 any, T2 any, U any](fn func(T1, T2) U, s1 Stream[T1], s2 Stream[T2]) Stream[U] {
+	return &zipWith2Stream[T1, T2, U]{s1: s1, s2: s2, fn: fn}
+}
+
+type zipWith3Stream[T1 any, T2 any, T3 any, U any] struct {
+	s1 Stream[T1]
+	s2 Stream[T2]
+	s3 Stream[T3]
+	fn func(T1, T2, T3) U
+}
+
+func (s *zipWith3Stream[T1, T2, T3, U]) Next() (U, error) {
+	x1, err1 := s.s1.Next()
+	x2, err2 := s.s2.Next()
+	x3, err3 := s.s3.Next()
+	if err := zipWithError(err1, err2, err3); err != nil {
+		return *new(U), err
+	}
+	return s.fn(x1, x2, x3), nil
+}
+
+func (s *zipWith3Stream[T1, T2, T3, U]) Close() error {
+	return errors.Join(StreamClose(s.s1), StreamClose(s.s2), StreamClose(s.s3))
+}
+
+// StreamZipWith3 is the stream counterpart of Map3: it returns a stream of
+// the results of fn applied to the corresponding elements of
+// s1, s2, s3. The streams are expected to end together, and the
+// resulting stream fails if they do not. Closing it closes all of them.
+func StreamZipWith3[T1 any, T2 any, T3 any, U any](fn func(T1, T2, T3) U, s1 Stream[T1], s2 Stream[T2], s3 Stream[T3]) Stream[U] {
+	return &zipWith3Stream[T1, T2, T3, U]{s1: s1, s2: s2, s3: s3, fn: fn}
+}
+
+type zipWith4Stream[T1 any, T2 any, T3 any, T4 any, U any] struct {
+	s1 Stream[T1]
+	s2 Stream[T2]
+	s3 Stream[T3]
+	s4 Stream[T4]
+	fn func(T1, T2, T3, T4) U
+}
+
+func (s *zipWith4Stream[T1, T2, T3, T4, U]) Next() (U, error) {
+	x1, err1 := s.s1.Next()
+	x2, err2 := s.s2.Next()
+	x3, err3 := s.s3.Next()
+	x4, err4 := s.s4.Next()
+	if err := zipWithError(err1, err2, err3, err4); err != nil {
+		return *new(U), err
+	}
+	return s.fn(x1, x2, x3, x4), nil
+}
+
+func (s *zipWith4Stream[T1, T2, T3, T4, U]) Close() error {
+	return errors.Join(StreamClose(s.s1), StreamClose(s.s2), StreamClose(s.s3), StreamClose(s.s4))
+}
+
+// StreamZipWith4 is the stream counterpart of Map4: it returns a stream of
+// the results of fn applied to the corresponding elements of
+// s1, s2, s3, s4. The streams are expected to end together, and the
+// resulting stream fails if they do not. Closing it closes all of them.
+func StreamZipWith4[T1 any, T2 any, T3 any, T4 any, U any](fn func(T1, T2, T3, T4) U, s1 Stream[T1], s2 Stream[T2], s3 Stream[T3], s4 Stream[T4]) Stream[U] {
+	return &zipWith4Stream[T1, T2, T3, T4, U]{s1: s1, s2: s2, s3: s3, s4: s4, fn: fn}
+}
+
+type zipWith5Stream[T1 any, T2 any, T3 any, T4 any, T5 any, U any] struct {
+	s1 Stream[T1]
+	s2 Stream[T2]
+	s3 Stream[T3]
+	s4 Stream[T4]
+	s5 Stream[T5]
+	fn func(T1, T2, T3, T4, T5) U
+}
+
+func (s *zipWith5Stream[T1, T2, T3, T4, T5, U]) Next() (U, error) {
+	x1, err1 := s.s1.Next()
+	x2, err2 := s.s2.Next()
+	x3, err3 := s.s3.Next()
+	x4, err4 := s.s4.Next()
+	x5, err5 := s.s5.Next()
+	if err := zipWithError(err1, err2, err3, err4, err5); err != nil {
+		return *new(U), err
+	}
+	return s.fn(x1, x2, x3, x4, x5), nil
+}
+
+func (s *zipWith5Stream[T1, T2, T3, T4, T5, U]) Close() error {
+	return errors.Join(StreamClose(s.s1), StreamClose(s.s2), StreamClose(s.s3), StreamClose(s.s4), StreamClose(s.s5))
+}
+
+// StreamZipWith5 is the stream counterpart of Map5: it returns a stream of
+// the results of fn applied to the corresponding elements of
+// s1, s2, s3, s4, s5. The streams are expected to end together, and the
+// resulting stream fails if they do not. Closing it closes all of them.
+func StreamZipWith5[T1 any, T2 any, T3 any, T4 any, T5 any, U any](fn func(T1, T2, T3, T4, T5) U, s1 Stream[T1], s2 Stream[T2], s3 Stream[T3], s4 Stream[T4], s5 Stream[T5]) Stream[U] {
+	return &zipWith5Stream[T1, T2, T3, T4, T5, U]{s1: s1, s2: s2, s3: s3, s4: s4, s5: s5, fn: fn}
+}
@@ -0,0 +1,73 @@
+package vino_test
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+
+	. "github.com/humbornjo/vino"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMap(t *testing.T) {
+	assert.Equal(t, []string{"1", "2", "3"}, Map(strconv.Itoa, []int{1, 2, 3}))
+
+	got, err := Map3(func(a int, b string, c bool) string {
+		return fmt.Sprint(a, b, c)
+	}, []int{1, 2}, []string{"a", "b"}, []bool{true, false})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1atrue", "2bfalse"}, got)
+
+	_, err = Map2(func(a int, b int) int { return a + b }, []int{1, 2}, []int{1})
+	assert.Error(t, err)
+
+	sum, err := Map5(func(a, b, c, d, e int) int { return a + b + c + d + e },
+		[]int{1}, []int{2}, []int{3}, []int{4}, []int{5})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{15}, sum)
+}
+
+func TestStreamZipWith(t *testing.T) {
+	concat := func(a int, b string) string { return strconv.Itoa(a) + b }
+
+	got, err := StreamCollect(StreamZipWith(concat, streamOf(1, 2), streamOf("a", "b")))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1a", "2b"}, got)
+
+	got, err = StreamCollect(StreamZipWith(concat, streamOf(1, 2), streamOf("a")))
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrStreamExhausted)
+	assert.Equal(t, []string{"1a"}, got)
+
+	errBoom := errors.New("boom")
+	s := StreamZipWith3(func(a, b, c int) int { return a * b * c },
+		streamOf(1, 2, 3), &failingStream{n: 2, err: errBoom}, streamOf(4, 5, 6))
+	xs, err := StreamCollect(s)
+	assert.ErrorIs(t, err, errBoom)
+	assert.Equal(t, []int{4, 0}, xs)
+}
+
+var benchXs, benchYs = func() ([]int, []int) {
+	xs, ys := make([]int, 1000), make([]int, 1000)
+	for i := range xs {
+		xs[i], ys[i] = i, 2*i
+	}
+	return xs, ys
+}()
+
+func benchAdd(a int, b int) int {
+	return a + b
+}
+
+func BenchmarkFunctionalMap(b *testing.B) {
+	for b.Loop() {
+		_, _ = FunctionalMap[int](benchAdd, benchXs, benchYs)
+	}
+}
+
+func BenchmarkMap2(b *testing.B) {
+	for b.Loop() {
+		_, _ = Map2(benchAdd, benchXs, benchYs)
+	}
+}
@@ -0,0 +1,449 @@
+package vino
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// PanicError is the error a panic is converted into when it is recovered
+// from a user function run on a worker goroutine. It keeps the recovered
+// value and the stack of the goroutine at the time of the panic.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the recovered value if it is an error, so that a panic
+// raised with an error can still be matched with errors.Is.
+func (e *PanicError) Unwrap() error {
+	if err, ok := e.Value.(error); ok {
+		return err
+	}
+	return nil
+}
+
+// ErrorPolicy selects how a concurrent stage reacts to failing elements.
+type ErrorPolicy int
+
+const (
+	// FailFast stops the stage at the first failure and reports it.
+	FailFast ErrorPolicy = iota
+	// CollectErrors skips failing elements and keeps going. The failures
+	// are joined with errors.Join and reported once everything else has
+	// been processed.
+	CollectErrors
+)
+
+// ParallelConfig tunes StreamParallelMap, SliceParallelMap and
+// FunctionalParallelMap.
+//
+// Fields:
+//   - Workers: The number of goroutines running the mapped function.
+//     Defaults to runtime.GOMAXPROCS(0).
+//   - Buffer: The maximum number of elements pulled from the source but not
+//     yet handed to the caller, which bounds the reorder buffer in ordered
+//     mode. Defaults to twice the number of workers.
+//   - Ordered: Whether results are emitted in input order.
+//   - Policy: How failing elements are dealt with.
+//   - Timeout: How long a single call to the mapped function may take. The
+//     context passed to it is cancelled after that, and the function is
+//     expected to give up and return its error. Zero means no timeout.
+type ParallelConfig struct {
+	Workers int
+	Buffer  int
+	Ordered bool
+	Policy  ErrorPolicy
+	Timeout time.Duration
+}
+
+type parallelJob[T any] struct {
+	idx int
+	x   T
+}
+
+type parallelResult[U any] struct {
+	idx int
+	y   U
+	err error
+}
+
+type parallelStream[T any, U any] struct {
+	parent  context.Context
+	ctx     context.Context
+	cancel  context.CancelFunc
+	s       Stream[T]
+	fn      func(context.Context, T) (U, error)
+	config  ParallelConfig
+	slots   chan struct{}
+	jobs    chan parallelJob[T]
+	results chan parallelResult[U]
+	fed     chan struct{}
+	pending map[int]parallelResult[U]
+	next    int
+	errs    []error
+	err     error
+}
+
+// StreamParallelMap returns a stream that yields fn(ctx, x) for every
+// element x of s, with fn running on a pool of worker goroutines. The
+// pool starts right away and keeps pulling from s in the background,
+// staying at most config.Buffer elements ahead of the caller.
+//
+// In ordered mode, results come out in the order of their inputs; in
+// unordered mode, they come out as soon as they are ready. Failures of fn
+// and of s are reported as *StreamError carrying the input position, and
+// panics in fn are recovered into *PanicError. With FailFast the first
+// failure to come back is returned right away, even if earlier elements
+// are still pending, and cancels the context passed to fn. Cancelling ctx
+// ends the stream with ctx.Err().
+//
+// Closing the stream stops the pool, waits for the pending call to s.Next
+// to return and closes s.
+func StreamParallelMap[T any, U any](
+	ctx context.Context,
+	s Stream[T],
+	fn func(context.Context, T) (U, error),
+	config ParallelConfig,
+) Stream[U] {
+	if config.Workers <= 0 {
+		config.Workers = runtime.GOMAXPROCS(0)
+	}
+	if config.Buffer <= 0 {
+		config.Buffer = 2 * config.Workers
+	}
+
+	cctx, cancel := context.WithCancel(ctx)
+	p := &parallelStream[T, U]{
+		parent:  ctx,
+		ctx:     cctx,
+		cancel:  cancel,
+		s:       s,
+		fn:      fn,
+		config:  config,
+		slots:   make(chan struct{}, config.Buffer),
+		jobs:    make(chan parallelJob[T]),
+		results: make(chan parallelResult[U], config.Buffer),
+		fed:     make(chan struct{}),
+		pending: make(map[int]parallelResult[U]),
+	}
+
+	wg := sync.WaitGroup{}
+	wg.Add(config.Workers + 1)
+	go func() {
+		defer wg.Done()
+		p.feed()
+	}()
+	for range config.Workers {
+		go func() {
+			defer wg.Done()
+			p.work()
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(p.results)
+	}()
+	return p
+}
+
+// feed pulls elements from the source into the job queue. A slot is taken
+// before every pull and handed back once the result leaves the stream, so
+// that results never block on a full results channel.
+func (p *parallelStream[T, U]) feed() {
+	defer close(p.fed)
+	defer close(p.jobs)
+	for idx := 0; ; idx++ {
+		select {
+		case p.slots <- struct{}{}:
+		case <-p.ctx.Done():
+			return
+		}
+		x, err := p.s.Next()
+		if errors.Is(err, ErrStreamExhausted) {
+			<-p.slots
+			return
+		}
+		if err != nil {
+			p.results <- parallelResult[U]{idx: idx, err: wrapStreamError("parallel map", idx, err)}
+			return
+		}
+		select {
+		case p.jobs <- parallelJob[T]{idx: idx, x: x}:
+		case <-p.ctx.Done():
+			return
+		}
+	}
+}
+
+func (p *parallelStream[T, U]) work() {
+	for job := range p.jobs {
+		y, err := p.call(job.x)
+		if err != nil {
+			err = wrapStreamError("parallel map", job.idx, err)
+		}
+		p.results <- parallelResult[U]{idx: job.idx, y: y, err: err}
+	}
+}
+
+func (p *parallelStream[T, U]) call(x T) (U, error) {
+	return parallelCall(p.ctx, p.config.Timeout, func(ctx context.Context) (U, error) {
+		return p.fn(ctx, x)
+	})
+}
+
+// parallelCall runs fn with a context bounded by timeout, if any, turning
+// a panic into a *PanicError.
+func parallelCall[U any](ctx context.Context, timeout time.Duration, fn func(context.Context) (U, error)) (y U, err error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return fn(ctx)
+}
+
+func (p *parallelStream[T, U]) Next() (U, error) {
+	for p.err == nil {
+		if r, ok := p.pending[p.next]; ok {
+			delete(p.pending, p.next)
+			p.next++
+			if y, ok := p.emit(r); ok {
+				return y, nil
+			}
+			continue
+		}
+
+		select {
+		case r, ok := <-p.results:
+			if !ok {
+				p.finish()
+				continue
+			}
+			if !p.config.Ordered || (r.err != nil && p.config.Policy == FailFast) {
+				if y, ok := p.emit(r); ok {
+					return y, nil
+				}
+				continue
+			}
+			p.pending[r.idx] = r
+		case <-p.ctx.Done():
+			if p.err == nil {
+				p.err = p.ctx.Err()
+			}
+		}
+	}
+	return *new(U), p.err
+}
+
+// emit hands the slot of r back and reports whether r carries a value for
+// the caller. Failures are either latched or collected depending on the
+// error policy.
+func (p *parallelStream[T, U]) emit(r parallelResult[U]) (U, bool) {
+	<-p.slots
+	if r.err == nil {
+		return r.y, true
+	}
+	if p.config.Policy == FailFast {
+		p.err = r.err
+		p.cancel()
+	} else {
+		p.errs = append(p.errs, r.err)
+	}
+	return *new(U), false
+}
+
+// finish ends the stream once every result came out. The results also run
+// out when the caller cancelled ctx, which is not a normal end.
+func (p *parallelStream[T, U]) finish() {
+	p.cancel()
+	if err := p.parent.Err(); err != nil {
+		p.err = err
+	} else if len(p.errs) > 0 {
+		p.err = errors.Join(p.errs...)
+	} else {
+		p.err = ErrStreamExhausted
+	}
+}
+
+func (p *parallelStream[T, U]) Close() error {
+	p.cancel()
+	<-p.fed
+	return StreamClose(p.s)
+}
+
+// ------------------------------------------------------------------------
+//  Parallel Slice Map
+// ------------------------------------------------------------------------
+
+// MapError reports the elements a parallel map failed on, by their index
+// in the input.
+type MapError struct {
+	Indices []int
+	Errs    []error
+}
+
+func (e *MapError) Error() string {
+	msgs := make([]string, len(e.Indices))
+	for i, idx := range e.Indices {
+		msgs[i] = fmt.Sprintf("index %d: %v", idx, e.Errs[i])
+	}
+	return fmt.Sprintf("%d element(s) failed: %s", len(e.Indices), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the failures, so that they can be matched with errors.Is
+// and errors.As.
+func (e *MapError) Unwrap() []error {
+	return e.Errs
+}
+
+// parallelMap calls fn for every index in [0, n) on a pool of workers and
+// gathers the results in order.
+func parallelMap[U any](
+	ctx context.Context,
+	n int,
+	fn func(context.Context, int) (U, error),
+	config ParallelConfig,
+) ([]U, error) {
+	if config.Workers <= 0 {
+		config.Workers = runtime.GOMAXPROCS(0)
+	}
+
+	cctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ret := make([]U, n)
+	errs := make([]error, n)
+	first := atomic.Int64{}
+	first.Store(-1)
+	next := atomic.Int64{}
+	wg := sync.WaitGroup{}
+	for range min(config.Workers, n) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for cctx.Err() == nil {
+				i := int(next.Add(1) - 1)
+				if i >= n {
+					return
+				}
+				y, err := parallelCall(cctx, config.Timeout, func(ctx context.Context) (U, error) {
+					return fn(ctx, i)
+				})
+				ret[i], errs[i] = y, err
+				if err != nil && config.Policy == FailFast && first.CompareAndSwap(-1, int64(i)) {
+					cancel()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if i := first.Load(); i >= 0 {
+		// The elements cancelled because of the first failure are not
+		// failures of their own.
+		return nil, &MapError{Indices: []int{int(i)}, Errs: []error{errs[i]}}
+	}
+	e := &MapError{}
+	for i, err := range errs {
+		if err != nil {
+			e.Indices = append(e.Indices, i)
+			e.Errs = append(e.Errs, err)
+		}
+	}
+	if len(e.Indices) > 0 {
+		return ret, e
+	}
+	return ret, nil
+}
+
+// SliceParallelMap applies fn to every element of xs on a pool of
+// config.Workers goroutines and returns the results in the order of xs.
+// Panics in fn are recovered into *PanicError, and config.Timeout bounds
+// every call to fn. config.Buffer and config.Ordered are not used.
+//
+// Failures are reported as a *MapError. With FailFast, the first failure
+// cancels the context passed to the remaining calls and is the only one
+// reported, and no results are returned. With CollectErrors, every element
+// is processed, and the results are returned along with the failures, the
+// results of the failed elements being left to their zero value.
+// Cancelling ctx stops the map and returns ctx.Err().
+func SliceParallelMap[T any, U any](
+	ctx context.Context,
+	xs []T,
+	fn func(context.Context, T) (U, error),
+	config ParallelConfig,
+) ([]U, error) {
+	return parallelMap(ctx, len(xs), func(ctx context.Context, i int) (U, error) {
+		return fn(ctx, xs[i])
+	}, config)
+}
+
+// FunctionalParallelMap is the concurrent counterpart of FunctionalMap. It
+// has the same requirements on fn and xss, except that fn takes a
+// context.Context as its first parameter and returns a second result of
+// type error, and it runs like SliceParallelMap.
+//
+// Example:
+//
+//	// func fetch(ctx context.Context, host string, port int) (Page, error)
+//	pages, err := FunctionalParallelMap[Page](ctx, ParallelConfig{
+//	    Workers: 8,
+//	    Timeout: time.Second,
+//	}, fetch, hosts, ports)
+func FunctionalParallelMap[T any](ctx context.Context, config ParallelConfig, fn any, xss ...any) ([]T, error) {
+	fnReflect := reflect.ValueOf(fn)
+	if fnReflect.Kind() != reflect.Func {
+		return nil, errors.New("fn is not a function pointer")
+	}
+
+	fnType := fnReflect.Type()
+	if fnType.NumIn() != len(xss)+1 {
+		return nil, errors.New("input parameter count mismatch")
+	}
+	if fnType.In(0) != reflect.TypeFor[context.Context]() {
+		return nil, errors.New("first input parameter is not a context.Context")
+	}
+	in, err := functionalInputs(fnType, 1, xss, AlignStrict())
+	if err != nil {
+		return nil, err
+	}
+
+	if fnType.NumOut() != 2 {
+		return nil, errors.New("output parameter count mismatch")
+	}
+	if fnType.Out(0) != reflect.TypeFor[T]() || fnType.Out(1) != reflect.TypeFor[error]() {
+		return nil, errors.New("output parameter type mismatch")
+	}
+
+	return parallelMap(ctx, in.n, func(ctx context.Context, i int) (T, error) {
+		args := make([]reflect.Value, len(xss)+1)
+		args[0] = reflect.ValueOf(&ctx).Elem()
+		for j := range xss {
+			args[j+1] = in.at(j, i)
+		}
+		out := fnReflect.Call(args)
+		y, _ := out[0].Interface().(T)
+		err, _ := out[1].Interface().(error)
+		return y, err
+	}, config)
+}
@@ -0,0 +1,206 @@
+package vino_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/humbornjo/vino"
+	"github.com/stretchr/testify/assert"
+)
+
+func rangeStream(n int) Stream[int] {
+	xs := make([]int, n)
+	for i := range xs {
+		xs[i] = i
+	}
+	return streamOf(xs...)
+}
+
+func TestStreamParallelMap(t *testing.T) {
+	square := func(_ context.Context, x int) (int, error) {
+		time.Sleep(time.Duration(x%7) * time.Millisecond)
+		return x * x, nil
+	}
+	want := make([]int, 100)
+	for i := range want {
+		want[i] = i * i
+	}
+
+	s := StreamParallelMap(context.Background(), rangeStream(100), square, ParallelConfig{
+		Workers: 8,
+		Buffer:  4,
+		Ordered: true,
+	})
+	got, err := StreamCollect(s)
+	assert.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	s = StreamParallelMap(context.Background(), rangeStream(100), square, ParallelConfig{Workers: 8})
+	got, err = StreamCollect(s)
+	assert.NoError(t, err)
+	slices.Sort(got)
+	assert.Equal(t, want, got)
+}
+
+func TestStreamParallelMap_Errors(t *testing.T) {
+	errOdd := errors.New("odd")
+	fn := func(_ context.Context, x int) (int, error) {
+		if x == 5 {
+			panic("five")
+		}
+		if x%2 == 1 {
+			return 0, errOdd
+		}
+		return x, nil
+	}
+
+	s := StreamParallelMap(context.Background(), rangeStream(10), fn, ParallelConfig{
+		Workers: 3,
+		Ordered: true,
+		Policy:  CollectErrors,
+	})
+	got, err := StreamCollect(s)
+	assert.Equal(t, []int{0, 2, 4, 6, 8}, got)
+	assert.ErrorIs(t, err, errOdd)
+	var perr *PanicError
+	if assert.ErrorAs(t, err, &perr) {
+		assert.Equal(t, "five", perr.Value)
+	}
+	var serr *StreamError
+	if assert.ErrorAs(t, err, &serr) {
+		assert.Equal(t, 1, serr.Index)
+	}
+
+	s = StreamParallelMap(context.Background(), rangeStream(10), fn, ParallelConfig{Workers: 3})
+	_, err = StreamCollect(s)
+	assert.Error(t, err)
+	_, again := s.Next()
+	assert.Equal(t, err, again)
+}
+
+func TestStreamParallelMap_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	block := func(ctx context.Context, x int) (int, error) {
+		if x > 0 {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		}
+		return x, nil
+	}
+
+	s := StreamParallelMap(ctx, rangeStream(10), block, ParallelConfig{Workers: 2, Ordered: true})
+	x, err := s.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, x)
+
+	cancel()
+	_, err = s.Next()
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.NoError(t, StreamClose(s))
+
+	// Cancelling while the pool still pulls from an endless source must not
+	// pass for the end of the stream.
+	identity := func(_ context.Context, x int) (int, error) { return x, nil }
+	for _, ordered := range []bool{false, true} {
+		for range 200 {
+			ctx, cancel := context.WithCancel(context.Background())
+			s := StreamParallelMap(ctx, Stream[int](&failingStream{n: math.MaxInt}), identity, ParallelConfig{Workers: 1, Buffer: 1, Ordered: ordered})
+			_, err := s.Next()
+			assert.NoError(t, err)
+			cancel()
+			// Give the pool time to wind down and close its results.
+			time.Sleep(100 * time.Microsecond)
+			for err == nil {
+				_, err = s.Next()
+			}
+			assert.ErrorIs(t, err, context.Canceled)
+			assert.NoError(t, StreamClose(s))
+		}
+	}
+}
+
+func TestSliceParallelMap(t *testing.T) {
+	errOdd := errors.New("odd")
+	fn := func(ctx context.Context, x int) (int, error) {
+		switch {
+		case x == 4:
+			panic("four")
+		case x == 6:
+			<-ctx.Done()
+			return 0, ctx.Err()
+		case x%2 == 1:
+			return 0, errOdd
+		}
+		return x * 10, nil
+	}
+
+	xs := []int{0, 1, 2, 3, 4, 5, 6, 8}
+	ys, err := SliceParallelMap(context.Background(), xs, fn, ParallelConfig{
+		Workers: 3,
+		Policy:  CollectErrors,
+		Timeout: 10 * time.Millisecond,
+	})
+	assert.Equal(t, []int{0, 0, 20, 0, 0, 0, 0, 80}, ys)
+
+	var merr *MapError
+	assert.ErrorAs(t, err, &merr)
+	assert.Equal(t, []int{1, 3, 4, 5, 6}, merr.Indices)
+	assert.ErrorIs(t, err, errOdd)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	var perr *PanicError
+	assert.ErrorAs(t, merr.Errs[2], &perr)
+	assert.Equal(t, "four", perr.Value)
+
+	ys, err = SliceParallelMap(context.Background(), xs, fn, ParallelConfig{Workers: 1})
+	assert.Nil(t, ys)
+	assert.ErrorAs(t, err, &merr)
+	assert.Equal(t, []int{1}, merr.Indices)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = SliceParallelMap(ctx, xs, fn, ParallelConfig{})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestFunctionalParallelMap(t *testing.T) {
+	join := func(_ context.Context, s string, n int) (string, error) {
+		if n < 0 {
+			return "", errors.New("negative")
+		}
+		return strings.Repeat(s, n), nil
+	}
+
+	ys, err := FunctionalParallelMap[string](context.Background(), ParallelConfig{Workers: 2}, join,
+		[]string{"a", "b", "c"}, []int{1, 2, 3})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "bb", "ccc"}, ys)
+
+	ys, err = FunctionalParallelMap[string](context.Background(), ParallelConfig{Policy: CollectErrors}, join,
+		[]string{"a", "b"}, []int{-1, 2})
+	assert.Equal(t, []string{"", "bb"}, ys)
+	var merr *MapError
+	assert.ErrorAs(t, err, &merr)
+	assert.Equal(t, []int{0}, merr.Indices)
+
+	bad := []struct {
+		fn  any
+		xss []any
+	}{
+		{42, []any{[]int{1}}},
+		{join, []any{[]string{"a"}}},
+		{func(s string, n int) (string, error) { return s, nil }, []any{[]string{"a"}}},
+		{join, []any{[]string{"a"}, []int{1, 2}}},
+		{join, []any{[]string{"a"}, []string{"b"}}},
+		{func(_ context.Context, s string) string { return s }, []any{[]string{"a"}}},
+		{func(_ context.Context, s string) (int, error) { return 0, nil }, []any{[]string{"a"}}},
+	}
+	for _, tt := range bad {
+		_, err := FunctionalParallelMap[string](context.Background(), ParallelConfig{}, tt.fn, tt.xss...)
+		assert.Error(t, err)
+	}
+}
@@ -0,0 +1,141 @@
+package vino
+
+import "errors"
+
+// Resetter is implemented by the streams that can be rewound to their
+// first element, such as the ones returned by NewRepeatedStream and
+// SliceToStream.
+type Resetter interface {
+	Reset() error
+}
+
+// Cloner is implemented by the streams that can be duplicated into an
+// independent stream positioned at the same element, such as the ones
+// returned by NewRepeatedStream and SliceToStream.
+type Cloner[T any] interface {
+	Clone() (Stream[T], error)
+}
+
+// StreamReset rewinds s if it implements Resetter, and fails otherwise.
+func StreamReset[T any](s Stream[T]) error {
+	if r, ok := s.(Resetter); ok {
+		return r.Reset()
+	}
+	return errors.New("stream is not resettable")
+}
+
+// StreamClone duplicates s if it implements Cloner, and fails otherwise.
+func StreamClone[T any](s Stream[T]) (Stream[T], error) {
+	if c, ok := s.(Cloner[T]); ok {
+		return c.Clone()
+	}
+	return nil, errors.New("stream is not cloneable")
+}
+
+// PeekableStream is a Stream that can look ahead and take elements back,
+// which is what parsers built on streams usually need.
+type PeekableStream[T any] interface {
+	Stream[T]
+	Resetter
+	Cloner[T]
+	// Peek returns the next n elements without consuming them. If the
+	// stream ends or fails before n elements are available, the ones that
+	// are available are returned along with the error that stopped it. A
+	// non-positive n returns an empty slice and no error.
+	Peek(n int) ([]T, error)
+	// Unread pushes xs back in front of the stream, so that the next call
+	// to Next returns xs[0].
+	Unread(xs ...T)
+}
+
+type peekStream[T any] struct {
+	s   Stream[T]
+	buf []T
+	err error
+}
+
+// fill buffers elements of the upstream stream until n are available or
+// the upstream stops. The error that stopped it is kept until the
+// buffered elements have been consumed.
+func (s *peekStream[T]) fill(n int) {
+	for len(s.buf) < n && s.err == nil {
+		x, err := s.s.Next()
+		if err != nil {
+			s.err = err
+			return
+		}
+		s.buf = append(s.buf, x)
+	}
+}
+
+func (s *peekStream[T]) Next() (T, error) {
+	s.fill(1)
+	if len(s.buf) == 0 {
+		err := s.err
+		if !errors.Is(err, ErrStreamExhausted) {
+			// A failure is reported once, leaving the upstream stream free
+			// to recover on the next call.
+			s.err = nil
+		}
+		return *new(T), err
+	}
+	x := s.buf[0]
+	s.buf = s.buf[1:]
+	return x, nil
+}
+
+func (s *peekStream[T]) Peek(n int) ([]T, error) {
+	if n <= 0 {
+		return []T{}, nil
+	}
+	s.fill(n)
+	ret := make([]T, min(n, len(s.buf)))
+	copy(ret, s.buf)
+	if len(ret) < n {
+		return ret, s.err
+	}
+	return ret, nil
+}
+
+func (s *peekStream[T]) Unread(xs ...T) {
+	buf := make([]T, 0, len(xs)+len(s.buf))
+	buf = append(buf, xs...)
+	s.buf = append(buf, s.buf...)
+}
+
+// Reset rewinds the upstream stream and drops everything buffered. It
+// fails if the upstream stream is not resettable.
+func (s *peekStream[T]) Reset() error {
+	if err := StreamReset(s.s); err != nil {
+		return err
+	}
+	s.buf, s.err = nil, nil
+	return nil
+}
+
+// Clone duplicates the upstream stream along with the elements buffered
+// so far. It fails if the upstream stream is not cloneable.
+func (s *peekStream[T]) Clone() (Stream[T], error) {
+	clone, err := StreamClone(s.s)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]T, len(s.buf))
+	copy(buf, s.buf)
+	return &peekStream[T]{s: clone, buf: buf, err: s.err}, nil
+}
+
+func (s *peekStream[T]) Close() error {
+	return StreamClose(s.s)
+}
+
+// NewPeekableStream wraps s with a lookahead buffer so that elements can
+// be peeked at and pushed back. Reset and Clone are delegated to s and
+// only succeed if s supports them. If s is already a PeekableStream it is
+// returned as is.
+func NewPeekableStream[T any](s Stream[T]) PeekableStream[T] {
+	if p, ok := s.(PeekableStream[T]); ok {
+		return p
+	}
+	return &peekStream[T]{s: s}
+}
@@ -0,0 +1,133 @@
+package vino_test
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	. "github.com/humbornjo/vino"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepeatedStream(t *testing.T) {
+	xs := []int{1, 2}
+	s := SliceToStream(xs, 1)
+	xs[0] = 100
+
+	got, err := StreamCollect(StreamTake(s, 3))
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 1}, got)
+
+	clone, err := StreamClone(s)
+	assert.NoError(t, err)
+	got, err = StreamCollect(s)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{2}, got)
+	got, err = StreamCollect(clone)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{2}, got)
+
+	assert.NoError(t, StreamReset(s))
+	got, err = StreamCollect(s)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 1, 2}, got)
+
+	assert.Error(t, StreamReset(streamOf(1)))
+	_, err = StreamClone(streamOf(1))
+	assert.Error(t, err)
+}
+
+func TestSliceToStream(t *testing.T) {
+	tests := []struct {
+		name   string
+		xs     []int
+		repeat []int
+		want   []int
+	}{
+		{"once", []int{1, 2, 3}, nil, []int{1, 2, 3}},
+		{"repeat", []int{1, 2}, []int{2}, []int{1, 2, 1, 2, 1, 2}},
+		{"empty", []int{}, nil, []int{}},
+		{"empty endless", nil, []int{-1}, []int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := StreamCollect(SliceToStream(tt.xs, tt.repeat...))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	got, err := StreamCollect(StreamTake(SliceToStream([]int{1, 2}, -1), 5))
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 1, 2, 1}, got)
+
+	// Adapters see every element of a slice-backed stream.
+	strs, err := StreamCollect(StreamMap(StreamFilter(SliceToStream([]int{1, 2, 3, 4}), func(x int) bool { return x%2 == 0 }), strconv.Itoa))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1", "3"}, strs)
+}
+
+func TestPeekableStream(t *testing.T) {
+	s := NewPeekableStream(streamOf(1, 2, 3))
+
+	xs, err := s.Peek(2)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, xs)
+
+	x, err := s.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, x)
+
+	xs, err = s.Peek(5)
+	assert.ErrorIs(t, err, ErrStreamExhausted)
+	assert.Equal(t, []int{2, 3}, xs)
+
+	for _, n := range []int{0, -1} {
+		xs, err = s.Peek(n)
+		assert.NoError(t, err)
+		assert.Empty(t, xs)
+	}
+
+	s.Unread(10, 11)
+	got, err := StreamCollect(s)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{10, 11, 2, 3}, got)
+
+	assert.Error(t, s.Reset())
+	assert.Same(t, s, NewPeekableStream[int](s))
+}
+
+func TestPeekableStream_ResetClone(t *testing.T) {
+	s := NewPeekableStream(NewRepeatedStream([]string{"a", "b", "c"}, 0))
+	_, err := s.Peek(2)
+	assert.NoError(t, err)
+
+	clone, err := s.Clone()
+	assert.NoError(t, err)
+	x, _ := s.Next()
+	assert.Equal(t, "a", x)
+	got, err := StreamCollect(clone)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, got)
+
+	assert.NoError(t, s.Reset())
+	got, err = StreamCollect(s)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, got)
+}
+
+func TestPeekableStream_Failure(t *testing.T) {
+	errBoom := errors.New("boom")
+	s := NewPeekableStream[int](&failingStream{n: 1, err: errBoom})
+
+	xs, err := s.Peek(3)
+	assert.ErrorIs(t, err, errBoom)
+	assert.Equal(t, []int{0}, xs)
+
+	x, err := s.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, x)
+	_, err = s.Next()
+	assert.ErrorIs(t, err, errBoom)
+}
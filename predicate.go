@@ -0,0 +1,215 @@
+package vino
+
+import (
+	"iter"
+	"strings"
+)
+
+// Predicate is a FilterFunc that can explain its decisions. Predicates are
+// built from FilterFunc and Named leaves combined with And, Or, Not, Xor,
+// AllOf, AnyOf and NoneOf. Like a FilterFunc, a predicate that holds for
+// an element means that the element is filtered out, and p.Test can be
+// passed wherever a FilterFunc is expected:
+//
+//	drop := Or(Named("minor", isMinor), Named("banned", isBanned))
+//	kept := FunctionalFilter(users, drop.Test)
+type Predicate[T any] interface {
+	// Test reports whether the predicate holds for x.
+	Test(x T) bool
+	// Explain evaluates the predicate on x like Test, but returns how every
+	// sub-predicate evaluated. Unlike Test, it does not short-circuit.
+	Explain(x T) Explanation
+}
+
+// Explanation is the outcome of a predicate evaluated on an element, along
+// with the outcomes of its sub-predicates.
+type Explanation struct {
+	Name     string
+	Result   bool
+	Children []Explanation
+}
+
+// String renders the explanation on a single line, such as
+// "or(minor=false, banned=true)=true".
+func (e Explanation) String() string {
+	b := strings.Builder{}
+	e.write(&b)
+	return b.String()
+}
+
+func (e Explanation) write(b *strings.Builder) {
+	b.WriteString(e.Name)
+	if e.Children != nil {
+		b.WriteByte('(')
+		for i, c := range e.Children {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			c.write(b)
+		}
+		b.WriteByte(')')
+	}
+	if e.Result {
+		b.WriteString("=true")
+	} else {
+		b.WriteString("=false")
+	}
+}
+
+// Reasons returns the names of the leaf predicates that decided the
+// result, that is the ones that made an element be filtered out when
+// Result is true, or kept when it is false.
+func (e Explanation) Reasons() []string {
+	var ret []string
+	var walk func(e Explanation, want bool)
+	walk = func(e Explanation, want bool) {
+		if e.Result != want {
+			return
+		}
+		if e.Children == nil {
+			ret = append(ret, e.Name)
+			return
+		}
+		for _, c := range e.Children {
+			switch e.Name {
+			case "not", "none":
+				// The operands of a negation decided the opposite way.
+				walk(c, !want)
+			case "xor":
+				walk(c, c.Result)
+			default:
+				walk(c, want)
+			}
+		}
+	}
+	walk(e, e.Result)
+	return ret
+}
+
+// Test reports whether f holds for x. A nil FilterFunc never holds.
+func (f FilterFunc[T]) Test(x T) bool {
+	return f != nil && f(x)
+}
+
+// Explain implements Predicate. An anonymous filter is named "filter", use
+// Named to give it a meaningful name.
+func (f FilterFunc[T]) Explain(x T) Explanation {
+	return Explanation{Name: "filter", Result: f.Test(x)}
+}
+
+type namedPredicate[T any] struct {
+	name string
+	f    FilterFunc[T]
+}
+
+func (p namedPredicate[T]) Test(x T) bool {
+	return p.f.Test(x)
+}
+
+func (p namedPredicate[T]) Explain(x T) Explanation {
+	return Explanation{Name: p.name, Result: p.f.Test(x)}
+}
+
+// Named returns a predicate that holds when f does, and that shows up as
+// name in explanations.
+func Named[T any](name string, f FilterFunc[T]) Predicate[T] {
+	return namedPredicate[T]{name: name, f: f}
+}
+
+type combinedPredicate[T any] struct {
+	op   string
+	ps   []Predicate[T]
+	eval func(results iter.Seq[bool]) bool
+}
+
+func (p combinedPredicate[T]) Test(x T) bool {
+	return p.eval(func(yield func(bool) bool) {
+		for _, q := range p.ps {
+			if !yield(q.Test(x)) {
+				return
+			}
+		}
+	})
+}
+
+func (p combinedPredicate[T]) Explain(x T) Explanation {
+	children := make([]Explanation, len(p.ps))
+	for i, q := range p.ps {
+		children[i] = q.Explain(x)
+	}
+	result := p.eval(func(yield func(bool) bool) {
+		for _, c := range children {
+			if !yield(c.Result) {
+				return
+			}
+		}
+	})
+	return Explanation{Name: p.op, Result: result, Children: children}
+}
+
+func allOf(results iter.Seq[bool]) bool {
+	for r := range results {
+		if !r {
+			return false
+		}
+	}
+	return true
+}
+
+func anyOf(results iter.Seq[bool]) bool {
+	for r := range results {
+		if r {
+			return true
+		}
+	}
+	return false
+}
+
+// And holds when both a and b hold.
+func And[T any](a Predicate[T], b Predicate[T]) Predicate[T] {
+	return combinedPredicate[T]{op: "and", ps: []Predicate[T]{a, b}, eval: allOf}
+}
+
+// Or holds when a or b holds.
+func Or[T any](a Predicate[T], b Predicate[T]) Predicate[T] {
+	return combinedPredicate[T]{op: "or", ps: []Predicate[T]{a, b}, eval: anyOf}
+}
+
+// Xor holds when exactly one of a and b holds.
+func Xor[T any](a Predicate[T], b Predicate[T]) Predicate[T] {
+	return combinedPredicate[T]{op: "xor", ps: []Predicate[T]{a, b}, eval: func(results iter.Seq[bool]) bool {
+		n := 0
+		for r := range results {
+			if r {
+				n++
+			}
+		}
+		return n == 1
+	}}
+}
+
+// Not holds when p does not.
+func Not[T any](p Predicate[T]) Predicate[T] {
+	return combinedPredicate[T]{op: "not", ps: []Predicate[T]{p}, eval: func(results iter.Seq[bool]) bool {
+		return !allOf(results)
+	}}
+}
+
+// AllOf holds when every one of ps holds, which is the case if ps is
+// empty.
+func AllOf[T any](ps ...Predicate[T]) Predicate[T] {
+	return combinedPredicate[T]{op: "all", ps: ps, eval: allOf}
+}
+
+// AnyOf holds when at least one of ps holds, which is never the case if ps
+// is empty.
+func AnyOf[T any](ps ...Predicate[T]) Predicate[T] {
+	return combinedPredicate[T]{op: "any", ps: ps, eval: anyOf}
+}
+
+// NoneOf holds when none of ps holds, which is the case if ps is empty.
+func NoneOf[T any](ps ...Predicate[T]) Predicate[T] {
+	return combinedPredicate[T]{op: "none", ps: ps, eval: func(results iter.Seq[bool]) bool {
+		return !anyOf(results)
+	}}
+}
@@ -0,0 +1,94 @@
+package vino_test
+
+import (
+	"testing"
+
+	. "github.com/humbornjo/vino"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterFunc_Append(t *testing.T) {
+	var f FilterFunc[int]
+	assert.Equal(t, []int{1, 2, 3}, FunctionalFilter([]int{1, 2, 3}, f))
+
+	// A nil filter keeps everything wherever it is accepted.
+	xs, err := StreamCollect(StreamFilter(streamOf(1, 2, 3), f))
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, xs)
+	kept, filtered := SlicePartition([]int{1, 2, 3}, f)
+	assert.Equal(t, []int{1, 2, 3}, kept)
+	assert.Empty(t, filtered)
+	kept, filtered, err = StreamPartition(streamOf(1, 2, 3), f)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, kept)
+	assert.Empty(t, filtered)
+
+	f.Append(nil)
+	f.Append(func(x int) bool { return x == 2 })
+	assert.Equal(t, []int{1, 3}, FunctionalFilter([]int{1, 2, 3}, f))
+
+	f.Append(func(x int) bool { return x == 3 })
+	assert.Equal(t, []int{1}, FunctionalFilter([]int{1, 2, 3}, f))
+}
+
+func TestPredicate(t *testing.T) {
+	even := Named("even", func(x int) bool { return x%2 == 0 })
+	big := Named("big", func(x int) bool { return x > 10 })
+	neg := Named("negative", func(x int) bool { return x < 0 })
+
+	tests := []struct {
+		name string
+		p    Predicate[int]
+		want []int
+	}{
+		{"and", And(even, big), []int{12}},
+		{"or", Or(even, big), []int{-2, 4, 11, 12}},
+		{"xor", Xor(even, big), []int{-2, 4, 11}},
+		{"not", Not(even), []int{-1, 3, 11}},
+		{"all", AllOf(even, Not(big), Not(neg)), []int{4}},
+		{"any", AnyOf(neg, big), []int{-2, -1, 11, 12}},
+		{"none", NoneOf(neg, big), []int{3, 4}},
+		{"empty all", AllOf[int](), []int{-2, -1, 3, 4, 11, 12}},
+		{"empty any", AnyOf[int](), []int{}},
+		{"filter", FilterFunc[int](func(x int) bool { return x == 3 }), []int{3}},
+	}
+
+	xs := []int{-2, -1, 3, 4, 11, 12}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []int{}
+			for _, x := range xs {
+				assert.Equal(t, tt.p.Test(x), tt.p.Explain(x).Result)
+				if tt.p.Test(x) {
+					got = append(got, x)
+				}
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	// Dropping the elements a predicate holds for, as FunctionalFilter does.
+	assert.Equal(t, []int{-1, 3}, FunctionalFilter(xs, Or(even, big).Test))
+}
+
+func TestPredicate_Explain(t *testing.T) {
+	type user struct {
+		age    int
+		banned bool
+	}
+	minor := Named("minor", func(u user) bool { return u.age < 18 })
+	banned := Named("banned", func(u user) bool { return u.banned })
+	drop := AnyOf(minor, banned, NoneOf(Named("vip", func(u user) bool { return u.age > 90 })))
+
+	e := drop.Explain(user{age: 30, banned: true})
+	assert.Equal(t, "any(minor=false, banned=true, none(vip=false)=true)=true", e.String())
+	assert.Equal(t, []string{"banned", "vip"}, e.Reasons())
+
+	e = drop.Explain(user{age: 95})
+	assert.False(t, e.Result)
+	assert.Equal(t, []string{"minor", "banned", "vip"}, e.Reasons())
+
+	e = Not(And(minor, banned)).Explain(user{age: 10})
+	assert.Equal(t, "not(and(minor=true, banned=false)=false)=true", e.String())
+	assert.Equal(t, []string{"banned"}, e.Reasons())
+}
@@ -1,6 +1,8 @@
 package vino
 
 import (
+	"container/heap"
+	"errors"
 	"sort"
 )
 
@@ -142,10 +144,147 @@ func (m MergeImpl[I, V]) Merge(xs0 []V, xs1 []V) []V {
 	return ret
 }
 
+// MergeStreams lazily merges the sorted streams ss into a single sorted
+// stream, holding only the head of each stream in memory. Elements with
+// equal keys come out in the order of the streams they belong to, then in
+// their order within that stream, and are handled according to dup. Any
+// failure other than ErrStreamExhausted from one of the streams ends the
+// merged stream with that failure. Closing the merged stream closes all of
+// ss.
+func (m MergeImpl[I, V]) MergeStreams(dup DuplicatePolicy[V], ss ...Stream[V]) Stream[V] {
+	return &mergeStream[I, V]{m: m, dup: dup, ss: ss}
+}
+
+// StreamMerge merges the streams ss, sorted by the key idx extracts, as
+// MergeImpl.MergeStreams does.
+func StreamMerge[I any, V any](idx func(V) I, cmp func(I, I) int, dup DuplicatePolicy[V], ss ...Stream[V]) Stream[V] {
+	return MergeImpl[I, V]{idx, cmp}.MergeStreams(dup, ss...)
+}
+
 func NewMergeImpl[I any, V any](idx func(V) I, cmp func(I, I) int) IfaceMerge[V] {
 	return MergeImpl[I, V]{idx, cmp}
 }
 
+// DuplicatePolicy tells StreamMerge what to do with elements sharing the
+// same key. Use KeepAll, KeepFirst or Combine to create one.
+type DuplicatePolicy[V any] struct {
+	combine func(V, V) V
+}
+
+// KeepAll keeps every element, duplicates included.
+func KeepAll[V any]() DuplicatePolicy[V] {
+	return DuplicatePolicy[V]{}
+}
+
+// KeepFirst keeps only the first of the elements sharing the same key.
+func KeepFirst[V any]() DuplicatePolicy[V] {
+	return Combine(func(x V, _ V) V { return x })
+}
+
+// Combine folds the elements sharing the same key into a single one with
+// fn, from the first to the last.
+func Combine[V any](fn func(V, V) V) DuplicatePolicy[V] {
+	return DuplicatePolicy[V]{combine: fn}
+}
+
+type mergeHead[V any] struct {
+	x V
+	i int
+}
+
+type mergeStream[I any, V any] struct {
+	m     MergeImpl[I, V]
+	dup   DuplicatePolicy[V]
+	ss    []Stream[V]
+	heads []mergeHead[V]
+	init  bool
+	err   error
+}
+
+func (s *mergeStream[I, V]) Len() int {
+	return len(s.heads)
+}
+
+func (s *mergeStream[I, V]) Less(i, j int) bool {
+	cmp := s.m.cmp(s.m.idx(s.heads[i].x), s.m.idx(s.heads[j].x))
+	if cmp == 0 {
+		return s.heads[i].i < s.heads[j].i
+	}
+	return cmp < 0
+}
+
+func (s *mergeStream[I, V]) Swap(i, j int) {
+	s.heads[i], s.heads[j] = s.heads[j], s.heads[i]
+}
+
+func (s *mergeStream[I, V]) Push(x any) {
+	s.heads = append(s.heads, x.(mergeHead[V]))
+}
+
+func (s *mergeStream[I, V]) Pop() any {
+	x := s.heads[len(s.heads)-1]
+	s.heads = s.heads[:len(s.heads)-1]
+	return x
+}
+
+// pull fetches the next element of the i-th stream into the heap.
+func (s *mergeStream[I, V]) pull(i int) error {
+	x, err := s.ss[i].Next()
+	if errors.Is(err, ErrStreamExhausted) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	heap.Push(s, mergeHead[V]{x: x, i: i})
+	return nil
+}
+
+// pop takes the smallest head out of the heap and refills it from the
+// stream it came from. A failure to refill is kept in s.err for the next
+// call to Next, so that the element already taken is not lost.
+func (s *mergeStream[I, V]) pop() V {
+	head := heap.Pop(s).(mergeHead[V])
+	s.err = s.pull(head.i)
+	return head.x
+}
+
+func (s *mergeStream[I, V]) Next() (V, error) {
+	if s.err != nil {
+		return *new(V), s.err
+	}
+	if !s.init {
+		s.init = true
+		s.heads = make([]mergeHead[V], 0, len(s.ss))
+		for i := range s.ss {
+			if s.err = s.pull(i); s.err != nil {
+				return *new(V), s.err
+			}
+		}
+	}
+	if len(s.heads) == 0 {
+		return *new(V), ErrStreamExhausted
+	}
+
+	x := s.pop()
+	if s.dup.combine == nil {
+		return x, nil
+	}
+	key := s.m.idx(x)
+	for s.err == nil && len(s.heads) > 0 && s.m.cmp(s.m.idx(s.heads[0].x), key) == 0 {
+		x = s.dup.combine(x, s.pop())
+	}
+	return x, nil
+}
+
+func (s *mergeStream[I, V]) Close() error {
+	errs := make([]error, 0, len(s.ss))
+	for _, ss := range s.ss {
+		errs = append(errs, StreamClose(ss))
+	}
+	return errors.Join(errs...)
+}
+
 type IfaceClamp[I any, V any] interface {
 	Clamp(xs []V, lo I, hi I) []V
 }
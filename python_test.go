@@ -1,6 +1,7 @@
 package vino_test
 
 import (
+	"errors"
 	"testing"
 
 	. "github.com/humbornjo/vino"
@@ -243,3 +244,72 @@ func TestClamperInt(t *testing.T) {
 		})
 	}
 }
+
+func TestMergeStreamsInt(t *testing.T) {
+	sum := func(a, b int) int { return a + b }
+
+	tests := []struct {
+		name string
+		dup  DuplicatePolicy[int]
+		xss  [][]int
+		want []int
+	}{
+		{
+			"no streams",
+			KeepAll[int](), [][]int{}, []int{},
+		},
+		{
+			"single stream",
+			KeepAll[int](), [][]int{{1, 2, 3}}, []int{1, 2, 3},
+		},
+		{
+			"keep all",
+			KeepAll[int](), [][]int{{1, 4, 7}, {}, {2, 4, 5}, {3, 4}}, []int{1, 2, 3, 4, 4, 4, 5, 7},
+		},
+		{
+			"keep first",
+			KeepFirst[int](), [][]int{{1, 1, 4}, {2, 4}, {4, 5}}, []int{1, 2, 4, 5},
+		},
+		{
+			"combine",
+			Combine(sum), [][]int{{1, 1, 4}, {2, 4}, {4, 5}}, []int{2, 2, 12, 5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ss := make([]Stream[int], len(tt.xss))
+			for i, xs := range tt.xss {
+				ss[i] = streamOf(xs...)
+			}
+			got, err := StreamCollect(StreamMerge(idxInt, cmpInt, tt.dup, ss...))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMergeStreamsOrder(t *testing.T) {
+	type kv struct {
+		k int
+		v string
+	}
+	s := StreamMerge(func(x kv) int { return x.k }, cmpInt, KeepAll[kv](), streamOf(kv{1, "a"}, kv{2, "a"}), streamOf(kv{1, "b"}, kv{2, "b"}))
+	got, err := StreamCollect(s)
+	assert.NoError(t, err)
+	assert.Equal(t, []kv{{1, "a"}, {1, "b"}, {2, "a"}, {2, "b"}}, got)
+}
+
+func TestMergeStreamsError(t *testing.T) {
+	boom := errors.New("boom")
+	for _, dup := range []DuplicatePolicy[int]{KeepAll[int](), KeepFirst[int]()} {
+		// The failing stream yields 0 then fails while 0 is being merged,
+		// which must still come out before the failure.
+		s := StreamMerge(idxInt, cmpInt, dup, &failingStream{n: 1, err: boom}, streamOf(0, 5))
+		got, err := StreamCollect(s)
+		assert.ErrorIs(t, err, boom)
+		assert.Equal(t, []int{0}, got)
+		_, err = s.Next()
+		assert.ErrorIs(t, err, boom)
+	}
+}
@@ -0,0 +1,281 @@
+package vino
+
+import (
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"reflect"
+	"slices"
+)
+
+// QuantileSketch estimates the quantiles of the numbers it was fed with
+// the KLL algorithm. It keeps a hierarchy of compactors, where an item of
+// level h stands for 2^h of the numbers added, and memory grows only with
+// the logarithm of the count. Any rank it reports is off by at most about
+// epsilon times the count with high probability, epsilon being the bound
+// the sketch was created with. The minimum and the maximum are exact.
+type QuantileSketch[N Number] struct {
+	k        int
+	levels   [][]N
+	size     int
+	count    uint64
+	min, max N
+	rng      *rand.Rand
+}
+
+// NewQuantileSketch returns an empty QuantileSketch whose rank error is
+// about epsilon, typically 0.01 for percentiles. The compactions are
+// randomized with rng, or the global source if rng is nil. Sketches can
+// only be merged if they were created with the same epsilon.
+func NewQuantileSketch[N Number](epsilon float64, rng *rand.Rand) *QuantileSketch[N] {
+	k := 8
+	if epsilon > 0 {
+		k = max(int(math.Ceil(2.3/epsilon)), k)
+	}
+	return &QuantileSketch[N]{k: k, levels: make([][]N, 1), rng: rng}
+}
+
+// capacity returns how many items the level h may hold before it is
+// compacted. The top level holds k items and every level below it holds
+// two thirds of the level above it.
+func (q *QuantileSketch[N]) capacity(h int) int {
+	depth := len(q.levels) - h - 1
+	return int(math.Ceil(math.Pow(2.0/3, float64(depth))*float64(q.k))) + 1
+}
+
+func (q *QuantileSketch[N]) full() bool {
+	limit := 0
+	for h := range q.levels {
+		limit += q.capacity(h)
+	}
+	return q.size >= limit
+}
+
+// compress compacts the lowest level that reached its capacity, adding a
+// level on top if needed.
+func (q *QuantileSketch[N]) compress() {
+	for h := range q.levels {
+		if len(q.levels[h]) < q.capacity(h) {
+			continue
+		}
+		if h+1 == len(q.levels) {
+			q.levels = append(q.levels, nil)
+		}
+
+		// Half of the sorted items, either the odd or the even ones, are
+		// promoted with twice the weight. An odd one out stays behind.
+		xs := q.levels[h]
+		slices.Sort(xs)
+		n := len(xs) &^ 1
+		for i := randIntN(q.rng, 2); i < n; i += 2 {
+			q.levels[h+1] = append(q.levels[h+1], xs[i])
+		}
+		q.levels[h] = append(xs[:0], xs[n:]...)
+		q.size -= n / 2
+		return
+	}
+}
+
+// Add records x.
+func (q *QuantileSketch[N]) Add(x N) {
+	if q.count == 0 {
+		q.min, q.max = x, x
+	}
+	q.min, q.max = min(q.min, x), max(q.max, x)
+	q.levels[0] = append(q.levels[0], x)
+	q.size++
+	q.count++
+	for q.full() {
+		q.compress()
+	}
+}
+
+// Count returns the number of values added so far.
+func (q *QuantileSketch[N]) Count() uint64 {
+	return q.count
+}
+
+// weighted returns the items held by the sketch sorted by value, along
+// with the cumulative weight up to and including each of them.
+func (q *QuantileSketch[N]) weighted() ([]N, []uint64) {
+	type item struct {
+		x N
+		w uint64
+	}
+	items := make([]item, 0, q.size)
+	for h, level := range q.levels {
+		for _, x := range level {
+			items = append(items, item{x: x, w: 1 << h})
+		}
+	}
+	slices.SortFunc(items, func(a, b item) int {
+		return cmp.Compare(a.x, b.x)
+	})
+
+	xs, cum := make([]N, len(items)), make([]uint64, len(items))
+	total := uint64(0)
+	for i, it := range items {
+		total += it.w
+		xs[i], cum[i] = it.x, total
+	}
+	return xs, cum
+}
+
+// Quantile returns the estimated p-quantile, for instance the median for
+// 0.5 and the 99th percentile for 0.99. A p of 0 or less returns the
+// minimum and a p of 1 or more returns the maximum. It returns zero if
+// nothing was added.
+func (q *QuantileSketch[N]) Quantile(p float64) N {
+	if q.count == 0 {
+		return 0
+	}
+	if p <= 0 {
+		return q.min
+	}
+	if p >= 1 {
+		return q.max
+	}
+	xs, cum := q.weighted()
+	target := uint64(math.Ceil(p * float64(cum[len(cum)-1])))
+	i, _ := slices.BinarySearch(cum, target)
+	return xs[min(i, len(xs)-1)]
+}
+
+// CDF returns the estimated fraction of the values added that are less
+// than or equal to x. It returns zero if nothing was added.
+func (q *QuantileSketch[N]) CDF(x N) float64 {
+	if q.count == 0 {
+		return 0
+	}
+	xs, cum := q.weighted()
+	i, found := slices.BinarySearch(xs, x)
+	for found && i < len(xs) && xs[i] == x {
+		i++
+	}
+	if i == 0 {
+		return 0
+	}
+	return float64(cum[i-1]) / float64(cum[len(cum)-1])
+}
+
+// Merge folds o into q, so that q estimates the quantiles of the values
+// added to either of them.
+func (q *QuantileSketch[N]) Merge(o *QuantileSketch[N]) error {
+	if q.k != o.k {
+		return errors.New("quantile sketch error bound mismatch")
+	}
+	if o.count == 0 {
+		return nil
+	}
+	if q.count == 0 {
+		q.min, q.max = o.min, o.max
+	}
+	q.min, q.max = min(q.min, o.min), max(q.max, o.max)
+	for len(q.levels) < len(o.levels) {
+		q.levels = append(q.levels, nil)
+	}
+	for h, level := range o.levels {
+		q.levels[h] = append(q.levels[h], level...)
+	}
+	q.size += o.size
+	q.count += o.count
+	for q.full() {
+		q.compress()
+	}
+	return nil
+}
+
+// appendNumber encodes x losslessly, as a varint for integers and as the
+// bits of a float64 otherwise.
+func appendNumber[N Number](buf []byte, x N) []byte {
+	switch reflect.TypeFor[N]().Kind() {
+	case reflect.Float32, reflect.Float64:
+		return binary.LittleEndian.AppendUint64(buf, math.Float64bits(float64(x)))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return binary.AppendVarint(buf, int64(x))
+	default:
+		return binary.AppendUvarint(buf, uint64(x))
+	}
+}
+
+func readNumber[N Number](r *sketchReader) N {
+	switch reflect.TypeFor[N]().Kind() {
+	case reflect.Float32, reflect.Float64:
+		b := r.bytes(8)
+		if b == nil {
+			return 0
+		}
+		return N(math.Float64frombits(binary.LittleEndian.Uint64(b)))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return N(r.varint())
+	default:
+		return N(r.uvarint())
+	}
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (q *QuantileSketch[N]) MarshalBinary() ([]byte, error) {
+	buf := []byte{sketchQuantile}
+	buf = binary.AppendUvarint(buf, uint64(q.k))
+	buf = binary.AppendUvarint(buf, q.count)
+	buf = appendNumber(buf, q.min)
+	buf = appendNumber(buf, q.max)
+	buf = binary.AppendUvarint(buf, uint64(len(q.levels)))
+	for _, level := range q.levels {
+		buf = binary.AppendUvarint(buf, uint64(len(level)))
+		for _, x := range level {
+			buf = appendNumber(buf, x)
+		}
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. The data must
+// have been produced by a sketch of the same type of numbers.
+func (q *QuantileSketch[N]) UnmarshalBinary(data []byte) error {
+	r := newSketchReader(sketchQuantile, data)
+	k, count := r.uvarint(), r.uvarint()
+	lo, hi := readNumber[N](r), readNumber[N](r)
+	n := r.uvarint()
+	if r.err == nil && (k == 0 || n == 0 || n > 64) {
+		r.err = errors.New("sketch is malformed")
+	}
+	if r.err != nil {
+		return r.err
+	}
+	levels, size := make([][]N, 0, n), 0
+	for r.err == nil && uint64(len(levels)) < n {
+		m := r.uvarint()
+		if m > uint64(len(r.buf)) {
+			r.err = errors.New("sketch is malformed")
+			break
+		}
+		level := make([]N, 0, m)
+		for r.err == nil && uint64(len(level)) < m {
+			level = append(level, readNumber[N](r))
+		}
+		levels = append(levels, level)
+		size += len(level)
+	}
+	if err := r.close(); err != nil {
+		return err
+	}
+	q.k, q.count, q.min, q.max = int(k), count, lo, hi
+	q.levels, q.size = levels, size
+	return nil
+}
+
+// StreamQuantileSketch drains s into a new QuantileSketch created with
+// epsilon and rng. On failure, the sketch of the values read so far is
+// returned along with the error.
+//
+// Example:
+//
+//	q, err := StreamQuantileSketch(latencies, 0.01, nil)
+//	p50, p99 := q.Quantile(0.5), q.Quantile(0.99)
+func StreamQuantileSketch[N Number](s Stream[N], epsilon float64, rng *rand.Rand) (*QuantileSketch[N], error) {
+	q := NewQuantileSketch[N](epsilon, rng)
+	return q, StreamForEach(s, q.Add)
+}
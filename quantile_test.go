@@ -0,0 +1,77 @@
+package vino_test
+
+import (
+	"encoding/binary"
+	"testing"
+
+	. "github.com/humbornjo/vino"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuantileSketch(t *testing.T) {
+	const n = 100000
+	rng := seeded()
+	xs := rng.Perm(n)
+
+	q, err := StreamQuantileSketch(SliceToStream(xs, 0), 0.01, rng)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(n), q.Count())
+
+	for _, p := range []float64{0.01, 0.25, 0.5, 0.9, 0.99} {
+		assert.InDelta(t, p*n, float64(q.Quantile(p)), 0.01*n, "p%v", p*100)
+		assert.InDelta(t, p, q.CDF(int(p*n)), 0.01, "cdf %v", p*n)
+	}
+	assert.Equal(t, 0, q.Quantile(0))
+	assert.Equal(t, n-1, q.Quantile(1))
+	assert.Equal(t, 0.0, q.CDF(-1))
+	assert.Equal(t, 1.0, q.CDF(n))
+
+	assert.Equal(t, 0.0, NewQuantileSketch[float64](0.01, nil).Quantile(0.5))
+}
+
+func TestQuantileSketch_Merge(t *testing.T) {
+	rng := seeded()
+	lo := NewQuantileSketch[float64](0.01, rng)
+	hi := NewQuantileSketch[float64](0.01, rng)
+	for i := range 50000 {
+		lo.Add(float64(i))
+		hi.Add(float64(i + 50000))
+	}
+
+	data, err := hi.MarshalBinary()
+	assert.NoError(t, err)
+	restored := NewQuantileSketch[float64](0.5, rng)
+	assert.NoError(t, restored.UnmarshalBinary(data))
+	assert.Equal(t, hi.Quantile(0.5), restored.Quantile(0.5))
+	assert.Equal(t, hi.CDF(75000), restored.CDF(75000))
+
+	assert.NoError(t, lo.Merge(restored))
+	assert.Equal(t, uint64(100000), lo.Count())
+	assert.InDelta(t, 50000, lo.Quantile(0.5), 1000)
+	assert.InDelta(t, 99000, lo.Quantile(0.99), 1000)
+	assert.Equal(t, 99999.0, lo.Quantile(1))
+
+	assert.Error(t, lo.Merge(NewQuantileSketch[float64](0.1, rng)))
+	assert.Error(t, restored.UnmarshalBinary(data[:len(data)-1]))
+
+	// A header claiming too many levels must not allocate them.
+	huge := append([]byte("Q"), 1, 0)
+	huge = append(huge, make([]byte, 16)...)
+	huge = binary.AppendUvarint(huge, 1<<60)
+	assert.EqualError(t, restored.UnmarshalBinary(huge), "sketch is malformed")
+}
+
+func TestQuantileSketch_Encoding(t *testing.T) {
+	q := NewQuantileSketch[int64](0.05, seeded())
+	for _, x := range []int64{-1 << 62, 3, -7, 1<<62 + 1} {
+		q.Add(x)
+	}
+	data, err := q.MarshalBinary()
+	assert.NoError(t, err)
+
+	restored := NewQuantileSketch[int64](0.05, nil)
+	assert.NoError(t, restored.UnmarshalBinary(data))
+	assert.Equal(t, int64(-1<<62), restored.Quantile(0))
+	assert.Equal(t, int64(1<<62+1), restored.Quantile(1))
+	assert.Equal(t, int64(-7), restored.Quantile(0.5))
+}
@@ -0,0 +1,355 @@
+package vino
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// RecordError reports a failure to decode a record read from an
+// io.Reader, along with where the record starts in the input. Record and
+// Line are 1-based; Line is left zero for formats that are not line
+// oriented. Offset is the byte offset of the first byte of the record.
+type RecordError struct {
+	Record int
+	Line   int
+	Offset int64
+	Err    error
+}
+
+func (e *RecordError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("record %d (offset %d): %v", e.Record, e.Offset, e.Err)
+	}
+	return fmt.Sprintf("record %d (line %d, offset %d): %v", e.Record, e.Line, e.Offset, e.Err)
+}
+
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
+// readerCloser closes r if it implements io.Closer. It backs the Close
+// method of every reader stream so that streams built on top of files
+// release them.
+func readerCloser(r io.Reader) error {
+	if c, ok := r.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// ------------------------------------------------------------------------
+//  Line Stream
+// ------------------------------------------------------------------------
+
+type lineStream struct {
+	r      io.Reader
+	br     *bufio.Reader
+	line   int
+	offset int64
+	err    error
+}
+
+// next reads the next line with its terminator stripped and reports the
+// line number and offset it starts at.
+func (s *lineStream) next() (string, int, int64, error) {
+	if s.err != nil {
+		return "", s.line, s.offset, s.err
+	}
+	line, err := s.br.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		if err == io.EOF {
+			s.err = ErrStreamExhausted
+		} else {
+			s.err = &RecordError{Record: s.line + 1, Line: s.line + 1, Offset: s.offset, Err: err}
+		}
+		return "", s.line, s.offset, s.err
+	}
+	offset := s.offset
+	s.line++
+	s.offset += int64(len(line))
+	line = strings.TrimSuffix(line, "\n")
+	line = strings.TrimSuffix(line, "\r")
+	return line, s.line, offset, nil
+}
+
+func (s *lineStream) Next() (string, error) {
+	line, _, _, err := s.next()
+	return line, err
+}
+
+func (s *lineStream) Close() error {
+	return readerCloser(s.r)
+}
+
+func newLineStream(r io.Reader) *lineStream {
+	return &lineStream{r: r, br: bufio.NewReader(r)}
+}
+
+// NewLineStream returns a stream over the lines of r. Both "\n" and
+// "\r\n" terminators are stripped, and a final line without terminator
+// is still yielded. Lines may be of any length. Closing the stream
+// closes r if it implements io.Closer.
+func NewLineStream(r io.Reader) Stream[string] {
+	return newLineStream(r)
+}
+
+// ------------------------------------------------------------------------
+//  CSV Stream
+// ------------------------------------------------------------------------
+
+type csvStream struct {
+	r      io.Reader
+	cr     *csv.Reader
+	config []func(*csv.Reader)
+	base   int64
+	record int
+	err    error
+}
+
+func (s *csvStream) Next() ([]string, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	offset := s.base + s.cr.InputOffset()
+	fields, err := s.cr.Read()
+	if err == io.EOF {
+		s.err = ErrStreamExhausted
+		return nil, s.err
+	}
+	s.record++
+	if err != nil {
+		rerr := &RecordError{Record: s.record, Offset: offset, Err: err}
+		var perr *csv.ParseError
+		if errors.As(err, &perr) {
+			rerr.Line = perr.StartLine
+		} else {
+			// Failures of the underlying reader are not recoverable.
+			s.err = rerr
+		}
+		return nil, rerr
+	}
+	return fields, nil
+}
+
+func (s *csvStream) Close() error {
+	return readerCloser(s.r)
+}
+
+// NewCSVStream returns a stream over the CSV records of r. The optional
+// config functions are applied to the underlying csv.Reader before the
+// first record is read, which allows setting the separator, comments or
+// the number of fields per record. A malformed record is reported as a
+// *RecordError wrapping the *csv.ParseError, and reading can resume with
+// the next record afterwards.
+func NewCSVStream(r io.Reader, config ...func(*csv.Reader)) Stream[[]string] {
+	s := &csvStream{r: r, config: config}
+	s.reset(r)
+	return s
+}
+
+// reset makes the stream read its records from r from now on.
+func (s *csvStream) reset(r io.Reader) {
+	s.cr = csv.NewReader(r)
+	for _, f := range s.config {
+		f(s.cr)
+	}
+}
+
+// ------------------------------------------------------------------------
+//  JSON Lines Stream
+// ------------------------------------------------------------------------
+
+type jsonlStream[T any] struct {
+	*lineStream
+	record int
+}
+
+func (s *jsonlStream[T]) Next() (T, error) {
+	for {
+		line, n, offset, err := s.next()
+		if err != nil {
+			return *new(T), err
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		s.record++
+		x := new(T)
+		if err := json.Unmarshal([]byte(line), x); err != nil {
+			return *new(T), &RecordError{Record: s.record, Line: n, Offset: offset, Err: err}
+		}
+		return *x, nil
+	}
+}
+
+// NewJSONLStream returns a stream that decodes every non-blank line of r
+// as a JSON value of type T. A line that fails to decode is reported as
+// a *RecordError, and reading can resume with the next line afterwards.
+func NewJSONLStream[T any](r io.Reader) Stream[T] {
+	return &jsonlStream[T]{lineStream: newLineStream(r)}
+}
+
+// ------------------------------------------------------------------------
+//  Length-Prefixed Stream
+// ------------------------------------------------------------------------
+
+type lengthPrefixedStream struct {
+	r       io.Reader
+	br      *bufio.Reader
+	maxSize uint32
+	record  int
+	offset  int64
+	err     error
+}
+
+func (s *lengthPrefixedStream) Next() ([]byte, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	fail := func(err error) ([]byte, error) {
+		s.err = &RecordError{Record: s.record + 1, Offset: s.offset, Err: err}
+		return nil, s.err
+	}
+
+	var prefix [4]byte
+	if _, err := io.ReadFull(s.br, prefix[:]); err != nil {
+		if err == io.EOF {
+			s.err = ErrStreamExhausted
+			return nil, s.err
+		}
+		return fail(err)
+	}
+	size := binary.BigEndian.Uint32(prefix[:])
+	if s.maxSize > 0 && size > s.maxSize {
+		return fail(fmt.Errorf("record size %d exceeds limit %d", size, s.maxSize))
+	}
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(s.br, buf); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return fail(err)
+	}
+	s.record++
+	s.offset += int64(len(prefix)) + int64(size)
+	return buf, nil
+}
+
+func (s *lengthPrefixedStream) Close() error {
+	return readerCloser(s.r)
+}
+
+// NewLengthPrefixedStream returns a stream over the records of r, each of
+// which is framed by its length as a 4-byte big-endian unsigned integer.
+// Records larger than maxSize are rejected before being allocated; a
+// maxSize of 0 disables the check. Since a broken frame cannot be skipped,
+// any failure ends the stream.
+func NewLengthPrefixedStream(r io.Reader, maxSize uint32) Stream[[]byte] {
+	return &lengthPrefixedStream{r: r, br: bufio.NewReader(r), maxSize: maxSize}
+}
+
+// ------------------------------------------------------------------------
+//  Stream Sinks
+// ------------------------------------------------------------------------
+//
+// The sinks drain a stream into an io.Writer and return the number of
+// records written. Like the stream terminals, they stop at the first
+// failure of either the stream or the writer, and leave closing both to
+// the caller.
+
+// StreamWriteLines writes every element of s to w followed by "\n".
+func StreamWriteLines(w io.Writer, s Stream[string]) (int, error) {
+	bw := bufio.NewWriter(w)
+	n := 0
+	err := streamSink(s, func(line string) error {
+		if _, err := bw.WriteString(line); err != nil {
+			return err
+		}
+		if err := bw.WriteByte('\n'); err != nil {
+			return err
+		}
+		n++
+		return nil
+	})
+	return n, errors.Join(err, bw.Flush())
+}
+
+// StreamWriteCSV writes every element of s to w as a CSV record. The
+// optional config functions are applied to the underlying csv.Writer.
+func StreamWriteCSV(w io.Writer, s Stream[[]string], config ...func(*csv.Writer)) (int, error) {
+	cw := csv.NewWriter(w)
+	for _, f := range config {
+		f(cw)
+	}
+	n := 0
+	err := streamSink(s, func(fields []string) error {
+		if err := cw.Write(fields); err != nil {
+			return err
+		}
+		n++
+		return nil
+	})
+	cw.Flush()
+	return n, errors.Join(err, cw.Error())
+}
+
+// StreamWriteJSONL writes every element of s to w as a JSON value on its
+// own line.
+func StreamWriteJSONL[T any](w io.Writer, s Stream[T]) (int, error) {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	n := 0
+	err := streamSink(s, func(x T) error {
+		if err := enc.Encode(x); err != nil {
+			return err
+		}
+		n++
+		return nil
+	})
+	return n, errors.Join(err, bw.Flush())
+}
+
+// StreamWriteLengthPrefixed writes every element of s to w framed by its
+// length, in the format read by NewLengthPrefixedStream.
+func StreamWriteLengthPrefixed(w io.Writer, s Stream[[]byte]) (int, error) {
+	bw := bufio.NewWriter(w)
+	n := 0
+	err := streamSink(s, func(buf []byte) error {
+		if uint64(len(buf)) > uint64(^uint32(0)) {
+			return fmt.Errorf("record size %d overflows length prefix", len(buf))
+		}
+		var prefix [4]byte
+		binary.BigEndian.PutUint32(prefix[:], uint32(len(buf)))
+		if _, err := bw.Write(prefix[:]); err != nil {
+			return err
+		}
+		if _, err := bw.Write(buf); err != nil {
+			return err
+		}
+		n++
+		return nil
+	})
+	return n, errors.Join(err, bw.Flush())
+}
+
+// streamSink drains s into write, stopping at the first error of either.
+func streamSink[T any](s Stream[T], write func(T) error) error {
+	for {
+		x, err := s.Next()
+		if errors.Is(err, ErrStreamExhausted) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := write(x); err != nil {
+			return err
+		}
+	}
+}
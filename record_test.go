@@ -0,0 +1,141 @@
+package vino_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io"
+	"strings"
+	"testing"
+
+	. "github.com/humbornjo/vino"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLineStream(t *testing.T) {
+	s := NewLineStream(strings.NewReader("a\r\nbb\n\nccc"))
+	lines, err := StreamCollect(s)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "bb", "", "ccc"}, lines)
+
+	buf := &bytes.Buffer{}
+	n, err := StreamWriteLines(buf, streamOf("x", "y"))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, "x\ny\n", buf.String())
+}
+
+func TestCSVStream(t *testing.T) {
+	input := "a,b\n\"c,d\",e\n\"broken,f\nx;y\n"
+	s := NewCSVStream(strings.NewReader(input))
+
+	x, err := s.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, x)
+	x, err = s.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"c,d", "e"}, x)
+
+	_, err = s.Next()
+	var rerr *RecordError
+	if assert.ErrorAs(t, err, &rerr) {
+		assert.Equal(t, 3, rerr.Record)
+		assert.Equal(t, 3, rerr.Line)
+		assert.Equal(t, int64(12), rerr.Offset)
+	}
+	var perr *csv.ParseError
+	assert.ErrorAs(t, err, &perr)
+
+	s = NewCSVStream(strings.NewReader("a;b\n"), func(r *csv.Reader) { r.Comma = ';' })
+	records, err := StreamCollect(s)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{"a", "b"}}, records)
+
+	buf := &bytes.Buffer{}
+	n, err := StreamWriteCSV(buf, streamOf([]string{"a", "b,c"}, []string{"d"}))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, "a,\"b,c\"\nd\n", buf.String())
+}
+
+func TestJSONLStream(t *testing.T) {
+	type item struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+
+	input := "{\"id\":1,\"name\":\"a\"}\n\n{\"id\":oops}\n{\"id\":3,\"name\":\"c\"}\n"
+	s := NewJSONLStream[item](strings.NewReader(input))
+
+	x, err := s.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, item{1, "a"}, x)
+
+	_, err = s.Next()
+	var rerr *RecordError
+	if assert.ErrorAs(t, err, &rerr) {
+		assert.Equal(t, 2, rerr.Record)
+		assert.Equal(t, 3, rerr.Line)
+		assert.Equal(t, int64(21), rerr.Offset)
+	}
+
+	// A bad line does not end the stream.
+	x, err = s.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, item{3, "c"}, x)
+	_, err = s.Next()
+	assert.ErrorIs(t, err, ErrStreamExhausted)
+
+	buf := &bytes.Buffer{}
+	n, err := StreamWriteJSONL(buf, streamOf(item{1, "a"}, item{2, "b"}))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	items, err := StreamCollect(NewJSONLStream[item](buf))
+	assert.NoError(t, err)
+	assert.Equal(t, []item{{1, "a"}, {2, "b"}}, items)
+}
+
+func TestLengthPrefixedStream(t *testing.T) {
+	buf := &bytes.Buffer{}
+	n, err := StreamWriteLengthPrefixed(buf, streamOf([]byte("hello"), []byte{}, []byte("world")))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	raw := buf.Bytes()
+	records, err := StreamCollect(NewLengthPrefixedStream(bytes.NewReader(raw), 0))
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("hello"), {}, []byte("world")}, records)
+
+	// Truncated input reports where the broken record starts.
+	s := NewLengthPrefixedStream(bytes.NewReader(raw[:len(raw)-1]), 0)
+	records, err = StreamCollect(s)
+	assert.Len(t, records, 2)
+	var rerr *RecordError
+	if assert.ErrorAs(t, err, &rerr) {
+		assert.Equal(t, 3, rerr.Record)
+		assert.Equal(t, int64(13), rerr.Offset)
+	}
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	_, err = NewLengthPrefixedStream(bytes.NewReader(raw), 4).Next()
+	assert.Error(t, err)
+}
+
+type closeTracker struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeTracker) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestRecordStreamClose(t *testing.T) {
+	r := &closeTracker{Reader: strings.NewReader("1\n2\n")}
+	s := StreamMap(NewJSONLStream[int](r), func(x int) int { return x * 2 })
+	x, err := s.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, x)
+	assert.NoError(t, StreamClose(s))
+	assert.True(t, r.closed)
+}
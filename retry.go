@@ -0,0 +1,159 @@
+package vino
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// Backoff returns how long to wait before the given retry, retries being
+// numbered from 1.
+type Backoff func(retry int) time.Duration
+
+// ConstantBackoff waits d before every retry.
+func ConstantBackoff(d time.Duration) Backoff {
+	return func(int) time.Duration { return d }
+}
+
+// ExponentialBackoff waits base before the first retry and doubles the
+// wait for every retry after that. A jitter between 0 and 1 shaves a
+// random fraction of up to jitter off every wait, so that concurrent
+// clients do not retry in lockstep. The randomness comes from rng, which
+// can be seeded for reproducible waits; a nil rng uses the global source.
+func ExponentialBackoff(base time.Duration, jitter float64, rng *rand.Rand) Backoff {
+	jitter = min(max(jitter, 0), 1)
+	return func(retry int) time.Duration {
+		// Clamp the exponent so the wait saturates instead of turning into
+		// an infinity, which the jitter would then turn into NaN.
+		d := min(float64(base)*math.Pow(2, float64(min(max(retry-1, 0), 63))), math.MaxInt64)
+		if jitter > 0 {
+			r := rand.Float64
+			if rng != nil {
+				r = rng.Float64
+			}
+			d -= d * jitter * r()
+		}
+		if d >= math.MaxInt64 {
+			return math.MaxInt64
+		}
+		return time.Duration(d)
+	}
+}
+
+// CappedBackoff limits the waits of b to at most limit.
+func CappedBackoff(b Backoff, limit time.Duration) Backoff {
+	return func(retry int) time.Duration {
+		return min(b(retry), limit)
+	}
+}
+
+// RetryConfig tunes Retry, RetryValue and StreamRetry.
+//
+// Fields:
+//   - Attempts: The maximum number of attempts, the first one included.
+//     Zero means no limit.
+//   - Backoff: The wait before every retry. Defaults to no wait.
+//   - Retryable: Tells whether an error is worth retrying. Defaults to
+//     retrying every error. ErrStreamExhausted is never retried.
+//   - OnRetry: Called with the number of the upcoming retry and the error
+//     that caused it, before waiting.
+//   - Clock: The clock the waits are measured with. Defaults to
+//     SystemClock.
+type RetryConfig struct {
+	Attempts  int
+	Backoff   Backoff
+	Retryable func(error) bool
+	OnRetry   func(retry int, err error)
+	Clock     Clock
+}
+
+// RetryError is returned once every allowed attempt has failed. It holds
+// the number of attempts made and the error of the last one.
+type RetryError struct {
+	Attempts int
+	Err      error
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("gave up after %d attempts: %v", e.Attempts, e.Err)
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// retry calls fn until it succeeds, fails with an error that is not worth
+// retrying, runs out of attempts or ctx is done.
+func retry[T any](ctx context.Context, config RetryConfig, fn func() (T, error)) (T, error) {
+	clock := clockOrSystem(config.Clock)
+	for attempt := 1; ; attempt++ {
+		x, err := fn()
+		if err == nil || errors.Is(err, ErrStreamExhausted) {
+			return x, err
+		}
+		if config.Retryable != nil && !config.Retryable(err) {
+			return *new(T), err
+		}
+		if config.Attempts > 0 && attempt >= config.Attempts {
+			return *new(T), &RetryError{Attempts: attempt, Err: err}
+		}
+		if config.OnRetry != nil {
+			config.OnRetry(attempt, err)
+		}
+
+		var wait time.Duration
+		if config.Backoff != nil {
+			wait = config.Backoff(attempt)
+		}
+		if err := ctx.Err(); err != nil {
+			return *new(T), err
+		}
+		select {
+		case <-clock.After(wait):
+		case <-ctx.Done():
+			return *new(T), ctx.Err()
+		}
+	}
+}
+
+// Retry calls fn until it succeeds, following config. It returns nil on
+// success, the error itself if it is not retryable, ctx.Err() if ctx is
+// done while waiting, and a *RetryError once config.Attempts is reached.
+func Retry(ctx context.Context, config RetryConfig, fn func(context.Context) error) error {
+	_, err := retry(ctx, config, func() (struct{}, error) {
+		return struct{}{}, fn(ctx)
+	})
+	return err
+}
+
+// RetryValue is like Retry for functions that return a value.
+func RetryValue[T any](ctx context.Context, config RetryConfig, fn func(context.Context) (T, error)) (T, error) {
+	return retry(ctx, config, func() (T, error) {
+		return fn(ctx)
+	})
+}
+
+type retryStream[T any] struct {
+	ctx    context.Context
+	s      Stream[T]
+	config RetryConfig
+}
+
+func (s *retryStream[T]) Next() (T, error) {
+	return retry(s.ctx, s.config, s.s.Next)
+}
+
+func (s *retryStream[T]) Close() error {
+	return StreamClose(s.s)
+}
+
+// StreamRetry wraps s so that a failing Next is called again following
+// config instead of failing the whole pipeline. It suits sources that can
+// be pulled again after a transient failure, and the attempts are counted
+// afresh for every element. Waits are cut short when ctx is done.
+func StreamRetry[T any](ctx context.Context, s Stream[T], config RetryConfig) Stream[T] {
+	return &retryStream[T]{ctx: ctx, s: s, config: config}
+}
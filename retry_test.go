@@ -0,0 +1,127 @@
+package vino_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	. "github.com/humbornjo/vino"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, time.Second, ConstantBackoff(time.Second)(5))
+
+	b := ExponentialBackoff(time.Millisecond, 0, nil)
+	assert.Equal(t, []time.Duration{1, 2, 4, 8}, []time.Duration{
+		b(1) / time.Millisecond, b(2) / time.Millisecond, b(3) / time.Millisecond, b(4) / time.Millisecond,
+	})
+
+	capped := CappedBackoff(b, 3*time.Millisecond)
+	assert.Equal(t, 2*time.Millisecond, capped(2))
+	assert.Equal(t, 3*time.Millisecond, capped(10))
+
+	jittered := ExponentialBackoff(time.Second, 0.5, rand.New(rand.NewPCG(1, 2)))
+	again := ExponentialBackoff(time.Second, 0.5, rand.New(rand.NewPCG(1, 2)))
+	for retry := 1; retry <= 5; retry++ {
+		d := jittered(retry)
+		full := time.Second << (retry - 1)
+		assert.LessOrEqual(t, d, full)
+		assert.GreaterOrEqual(t, d, full/2)
+		assert.Equal(t, d, again(retry))
+	}
+
+	// Large retry counts saturate rather than overflow.
+	for _, retry := range []int{64, 1100, math.MaxInt} {
+		assert.Equal(t, time.Duration(math.MaxInt64), b(retry))
+		assert.Greater(t, jittered(retry), time.Duration(0))
+		assert.Equal(t, time.Second, CappedBackoff(jittered, time.Second)(retry))
+	}
+	assert.Equal(t, time.Duration(0), ExponentialBackoff(0, 0.5, nil)(1100))
+}
+
+func TestRetry(t *testing.T) {
+	errFlaky := errors.New("flaky")
+	errFatal := errors.New("fatal")
+	ctx := context.Background()
+
+	calls := 0
+	retries := []int{}
+	err := Retry(ctx, RetryConfig{
+		OnRetry: func(retry int, _ error) { retries = append(retries, retry) },
+	}, func(context.Context) error {
+		if calls++; calls < 3 {
+			return errFlaky
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, retries)
+
+	calls = 0
+	_, err = RetryValue(ctx, RetryConfig{Attempts: 4}, func(context.Context) (int, error) {
+		calls++
+		return 0, errFlaky
+	})
+	var rerr *RetryError
+	if assert.ErrorAs(t, err, &rerr) {
+		assert.Equal(t, 4, rerr.Attempts)
+	}
+	assert.ErrorIs(t, err, errFlaky)
+	assert.Equal(t, 4, calls)
+
+	calls = 0
+	err = Retry(ctx, RetryConfig{
+		Retryable: func(err error) bool { return !errors.Is(err, errFatal) },
+	}, func(context.Context) error {
+		calls++
+		return errFatal
+	})
+	assert.Equal(t, errFatal, err)
+	assert.Equal(t, 1, calls)
+
+	cctx, cancel := context.WithCancel(ctx)
+	clock := NewManualClock(time.Unix(0, 0))
+	done := make(chan error)
+	go func() {
+		done <- Retry(cctx, RetryConfig{Backoff: ConstantBackoff(time.Hour), Clock: clock}, func(context.Context) error {
+			return errFlaky
+		})
+	}()
+	clock.BlockUntil(1)
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
+
+// flakyStream fails every other call to Next.
+type flakyStream struct {
+	xs    []int
+	flaky bool
+}
+
+func (s *flakyStream) Next() (int, error) {
+	if s.flaky = !s.flaky; s.flaky {
+		return 0, errors.New("flaky")
+	}
+	if len(s.xs) == 0 {
+		return 0, ErrStreamExhausted
+	}
+	x := s.xs[0]
+	s.xs = s.xs[1:]
+	return x, nil
+}
+
+func TestStreamRetry(t *testing.T) {
+	retries := 0
+	s := StreamRetry[int](context.Background(), &flakyStream{xs: []int{1, 2, 3}}, RetryConfig{
+		Attempts: 2,
+		OnRetry:  func(int, error) { retries++ },
+	})
+	xs, err := StreamCollect(s)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, xs)
+	assert.Equal(t, 4, retries)
+}
@@ -0,0 +1,210 @@
+package vino
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// RollingWindow tells a rolling operator which of the latest elements it
+// aggregates. Use RollingCount or RollingSpan to create one. The zero
+// RollingWindow aggregates the latest element only, like RollingCount(1).
+type RollingWindow struct {
+	size int
+	span time.Duration
+}
+
+// RollingCount aggregates the last size elements.
+func RollingCount(size int) RollingWindow {
+	return RollingWindow{size: max(size, 1)}
+}
+
+// RollingSpan aggregates the elements that arrived within span of the
+// latest one, that is in (now-span, now]. A span shorter than a
+// nanosecond is rounded up to it.
+func RollingSpan(span time.Duration) RollingWindow {
+	return RollingWindow{span: max(span, time.Nanosecond)}
+}
+
+// RollingStats holds the statistics of a rolling window after an element
+// was added to it. Mean and Variance are zero for an empty window, and
+// Variance is the population variance.
+//
+// Unlike the other statistics, EWMA is not limited to the elements in the
+// window: it is an exponentially weighted moving average whose smoothing
+// follows the window. For RollingCount(n) every element has a weight of
+// 2/(n+1), as is customary, and for RollingSpan(d) an element that comes
+// dt after the previous one has a weight of 1-exp(-dt/d).
+type RollingStats[N Number] struct {
+	Count    int
+	Sum      N
+	Mean     float64
+	Min      N
+	Max      N
+	Variance float64
+	EWMA     float64
+}
+
+type rollingItem[N Number] struct {
+	seq int
+	at  time.Time
+	x   N
+}
+
+// Rolling maintains RollingStats over a RollingWindow in O(1) amortized
+// time per element. The sum, mean and variance are updated incrementally,
+// the latter with Welford's algorithm, and the extrema are tracked with
+// monotonic deques.
+type Rolling[N Number] struct {
+	window RollingWindow
+	items  []rollingItem[N]
+	mins   []rollingItem[N]
+	maxs   []rollingItem[N]
+	seq    int
+	sum    N
+	mean   float64
+	m2     float64
+	ewma   float64
+	last   time.Time
+}
+
+// NewRolling returns an empty Rolling over window.
+func NewRolling[N Number](window RollingWindow) *Rolling[N] {
+	if window.span <= 0 {
+		window = RollingCount(window.size)
+	}
+	return &Rolling[N]{window: window}
+}
+
+// Add adds x, which arrived at the given time, to the window, evicts the
+// elements that fell out of it, and returns the updated statistics. The
+// time only matters for a RollingSpan and is expected not to go backward.
+func (r *Rolling[N]) Add(at time.Time, x N) RollingStats[N] {
+	r.updateEWMA(at, float64(x))
+
+	item := rollingItem[N]{seq: r.seq, at: at, x: x}
+	r.seq++
+	r.items = append(r.items, item)
+	r.sum += x
+	d := float64(x) - r.mean
+	r.mean += d / float64(len(r.items))
+	r.m2 += d * (float64(x) - r.mean)
+
+	for len(r.mins) > 0 && r.mins[len(r.mins)-1].x >= x {
+		r.mins = r.mins[:len(r.mins)-1]
+	}
+	r.mins = append(r.mins, item)
+	for len(r.maxs) > 0 && r.maxs[len(r.maxs)-1].x <= x {
+		r.maxs = r.maxs[:len(r.maxs)-1]
+	}
+	r.maxs = append(r.maxs, item)
+
+	for len(r.items) > 0 && r.expired(r.items[0], item) {
+		r.evict()
+	}
+	return r.Stats()
+}
+
+func (r *Rolling[N]) updateEWMA(at time.Time, x float64) {
+	if r.seq == 0 {
+		r.ewma, r.last = x, at
+		return
+	}
+	alpha := 2 / (float64(r.window.size) + 1)
+	if r.window.span > 0 {
+		alpha = 1 - math.Exp(-float64(at.Sub(r.last))/float64(r.window.span))
+	}
+	r.ewma += alpha * (x - r.ewma)
+	r.last = at
+}
+
+// expired reports whether item fell out of the window now that latest was
+// added.
+func (r *Rolling[N]) expired(item rollingItem[N], latest rollingItem[N]) bool {
+	if r.window.span > 0 {
+		return !item.at.After(latest.at.Add(-r.window.span))
+	}
+	return latest.seq-item.seq >= r.window.size
+}
+
+// evict removes the oldest element of the window.
+func (r *Rolling[N]) evict() {
+	item := r.items[0]
+	r.items = r.items[1:]
+	if r.mins[0].seq == item.seq {
+		r.mins = r.mins[1:]
+	}
+	if r.maxs[0].seq == item.seq {
+		r.maxs = r.maxs[1:]
+	}
+
+	r.sum -= item.x
+	if len(r.items) == 0 {
+		r.sum, r.mean, r.m2 = 0, 0, 0
+		return
+	}
+	d := float64(item.x) - r.mean
+	r.mean -= d / float64(len(r.items))
+	r.m2 = max(r.m2-d*(float64(item.x)-r.mean), 0)
+}
+
+// Stats returns the statistics of the current window.
+func (r *Rolling[N]) Stats() RollingStats[N] {
+	stats := RollingStats[N]{Count: len(r.items), Sum: r.sum, Mean: r.mean, EWMA: r.ewma}
+	if len(r.items) > 0 {
+		stats.Min, stats.Max = r.mins[0].x, r.maxs[0].x
+		stats.Variance = r.m2 / float64(len(r.items))
+	}
+	return stats
+}
+
+type rollingStream[N Number] struct {
+	s     Stream[N]
+	r     *Rolling[N]
+	clock Clock
+}
+
+func (s *rollingStream[N]) Next() (RollingStats[N], error) {
+	x, err := s.s.Next()
+	if err != nil {
+		return RollingStats[N]{}, err
+	}
+	return s.r.Add(s.clock.Now(), x), nil
+}
+
+func (s *rollingStream[N]) Close() error {
+	return StreamClose(s.s)
+}
+
+// StreamRolling returns a stream of the rolling statistics of s over
+// window, one for every element of s, each accounting for that element.
+// The elements of a RollingSpan are timed by their arrival according to
+// clock, which defaults to SystemClock if nil.
+//
+// Example:
+//
+//	// The mean latency over the last 5 minutes, for every request.
+//	StreamMap(StreamRolling(latencies, RollingSpan(5*time.Minute), nil),
+//	    func(x RollingStats[float64]) float64 { return x.Mean })
+func StreamRolling[N Number](s Stream[N], window RollingWindow, clock Clock) Stream[RollingStats[N]] {
+	return &rollingStream[N]{s: s, r: NewRolling[N](window), clock: clockOrSystem(clock)}
+}
+
+// SliceRolling is the slice counterpart of StreamRolling. times holds the
+// time of every element of xs and is only needed by a RollingSpan; it may
+// be nil for a RollingCount. If given, it must be as long as xs.
+func SliceRolling[N Number](xs []N, window RollingWindow, times []time.Time) ([]RollingStats[N], error) {
+	if (window.span > 0 || times != nil) && len(times) != len(xs) {
+		return nil, errors.New("rolling window needs a time for every element")
+	}
+	r := NewRolling[N](window)
+	ret := make([]RollingStats[N], len(xs))
+	for i, x := range xs {
+		var at time.Time
+		if times != nil {
+			at = times[i]
+		}
+		ret[i] = r.Add(at, x)
+	}
+	return ret, nil
+}
@@ -0,0 +1,106 @@
+package vino_test
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	. "github.com/humbornjo/vino"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSliceRolling(t *testing.T) {
+	xs := []int{3, 1, 4, 1, 5, 9, 2, 6}
+	stats, err := SliceRolling(xs, RollingCount(3), nil)
+	assert.NoError(t, err)
+
+	field := func(f func(RollingStats[int]) int) []int {
+		ret := make([]int, len(stats))
+		for i, x := range stats {
+			ret[i] = f(x)
+		}
+		return ret
+	}
+	assert.Equal(t, []int{1, 2, 3, 3, 3, 3, 3, 3}, field(func(x RollingStats[int]) int { return x.Count }))
+	assert.Equal(t, []int{3, 4, 8, 6, 10, 15, 16, 17}, field(func(x RollingStats[int]) int { return x.Sum }))
+	assert.Equal(t, []int{3, 1, 1, 1, 1, 1, 2, 2}, field(func(x RollingStats[int]) int { return x.Min }))
+	assert.Equal(t, []int{3, 3, 4, 4, 5, 9, 9, 9}, field(func(x RollingStats[int]) int { return x.Max }))
+	assert.InDelta(t, 14.0/9, stats[2].Variance, 1e-9)
+	assert.InDelta(t, 32.0/3, stats[5].Variance, 1e-9)
+	assert.InDelta(t, 5.0, stats[5].Mean, 1e-9)
+
+	// An alpha of 2/(3+1) halves the distance to every new element.
+	assert.Equal(t, []float64{3, 2, 3, 2}, []float64{stats[0].EWMA, stats[1].EWMA, stats[2].EWMA, stats[3].EWMA})
+
+	_, err = SliceRolling(xs, RollingSpan(time.Second), nil)
+	assert.Error(t, err)
+	_, err = SliceRolling(xs, RollingCount(3), []time.Time{{}})
+	assert.EqualError(t, err, "rolling window needs a time for every element")
+
+	// Degenerate windows hold the latest element only.
+	epoch := time.Unix(0, 0)
+	times := make([]time.Time, len(xs))
+	for i := range times {
+		times[i] = epoch.Add(time.Duration(i) * time.Second)
+	}
+	for _, window := range []RollingWindow{{}, RollingCount(0), RollingCount(-1), RollingSpan(0), RollingSpan(-time.Second)} {
+		stats, err := SliceRolling(xs, window, times)
+		assert.NoError(t, err)
+		for i, st := range stats {
+			assert.Equal(t, RollingStats[int]{Count: 1, Sum: xs[i], Mean: float64(xs[i]), Min: xs[i], Max: xs[i], EWMA: float64(xs[i])}, st)
+		}
+	}
+}
+
+func TestSliceRolling_Naive(t *testing.T) {
+	rng := seeded()
+	xs := make([]float64, 1000)
+	for i := range xs {
+		xs[i] = rng.NormFloat64()*10 + 100
+	}
+
+	const size = 17
+	stats, err := SliceRolling(xs, RollingCount(size), nil)
+	assert.NoError(t, err)
+	for i, got := range stats {
+		w := xs[max(0, i-size+1) : i+1]
+		sum, sq := 0.0, 0.0
+		for _, x := range w {
+			sum += x
+		}
+		mean := sum / float64(len(w))
+		for _, x := range w {
+			sq += (x - mean) * (x - mean)
+		}
+		assert.Equal(t, len(w), got.Count)
+		assert.InDelta(t, sum, got.Sum, 1e-6)
+		assert.InDelta(t, mean, got.Mean, 1e-6)
+		assert.InDelta(t, sq/float64(len(w)), got.Variance, 1e-6)
+		assert.Equal(t, slices.Min(w), got.Min)
+		assert.Equal(t, slices.Max(w), got.Max)
+	}
+}
+
+func TestStreamRolling(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	s := &tickingStream{
+		clock: clock,
+		xs:    []int{1, 2, 3, 4, 5},
+		gaps:  []time.Duration{0, time.Second, time.Second, 2 * time.Second, 0},
+	}
+	stats, err := StreamCollect(StreamRolling[int](s, RollingSpan(2*time.Second), clock))
+	assert.NoError(t, err)
+
+	// Times are 0s, 1s, 2s, 4s and 4s, and every window is (now-2s, now].
+	sums := make([]int, len(stats))
+	for i, x := range stats {
+		sums[i] = x.Sum
+	}
+	assert.Equal(t, []int{1, 3, 5, 4, 9}, sums)
+	assert.Equal(t, 4, stats[4].Min)
+
+	// The EWMA moves by 1-exp(-1s/2s) toward 2 after a second.
+	assert.InDelta(t, 1+(1-math.Exp(-0.5)), stats[1].EWMA, 1e-9)
+	assert.InDelta(t, stats[3].EWMA, stats[4].EWMA, 1e-9)
+}
@@ -0,0 +1,168 @@
+package vino
+
+import (
+	"container/heap"
+	"math"
+	"math/rand/v2"
+)
+
+// The samplers take their randomness from a *rand.Rand so that a seeded
+// source makes them reproducible. A nil one falls back to the global
+// source of math/rand/v2.
+
+func randFloat64(rng *rand.Rand) float64 {
+	if rng == nil {
+		return rand.Float64()
+	}
+	return rng.Float64()
+}
+
+func randIntN(rng *rand.Rand, n int) int {
+	if rng == nil {
+		return rand.IntN(n)
+	}
+	return rng.IntN(n)
+}
+
+// reservoir keeps a uniform sample of k elements out of the ones offered
+// so far, following Algorithm R.
+type reservoir[T any] struct {
+	k    int
+	seen int
+	xs   []T
+}
+
+func (r *reservoir[T]) offer(x T, rng *rand.Rand) {
+	r.seen++
+	if len(r.xs) < r.k {
+		r.xs = append(r.xs, x)
+		return
+	}
+	if i := randIntN(rng, r.seen); i < r.k {
+		r.xs[i] = x
+	}
+}
+
+// StreamReservoirSample drains s and returns a uniform random sample of k
+// of its elements, or all of them if s yields fewer than k, using
+// Algorithm R. Only the sample is held in memory. The order of the sample
+// is not meaningful.
+func StreamReservoirSample[T any](s Stream[T], k int, rng *rand.Rand) ([]T, error) {
+	r := &reservoir[T]{k: k, xs: make([]T, 0, max(k, 0))}
+	err := StreamForEach(s, func(x T) {
+		r.offer(x, rng)
+	})
+	return r.xs, err
+}
+
+type weightedEntry[T any] struct {
+	key float64
+	x   T
+}
+
+// weightedHeap is a min-heap on the keys of A-Res, so that the entry to
+// evict is always on top.
+type weightedHeap[T any] []weightedEntry[T]
+
+func (h weightedHeap[T]) Len() int {
+	return len(h)
+}
+
+func (h weightedHeap[T]) Less(i, j int) bool {
+	return h[i].key < h[j].key
+}
+
+func (h weightedHeap[T]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+}
+
+func (h *weightedHeap[T]) Push(x any) {
+	*h = append(*h, x.(weightedEntry[T]))
+}
+
+func (h *weightedHeap[T]) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// StreamWeightedSample drains s and returns a random sample of k of its
+// elements without replacement, where the chance of an element to be
+// picked is proportional to weight(x), using the A-Res algorithm of
+// Efraimidis and Spirakis. Elements with a weight that is not positive are
+// never picked. The order of the sample is not meaningful.
+func StreamWeightedSample[T any](s Stream[T], k int, weight func(T) float64, rng *rand.Rand) ([]T, error) {
+	h := make(weightedHeap[T], 0, max(k, 0))
+	err := StreamForEach(s, func(x T) {
+		w := weight(x)
+		if w <= 0 || k <= 0 {
+			return
+		}
+		// Use log(u)/w instead of u^(1/w), which sorts the same but does
+		// not underflow for small weights.
+		key := math.Log(randFloat64(rng)) / w
+		if len(h) < k {
+			heap.Push(&h, weightedEntry[T]{key: key, x: x})
+		} else if key > h[0].key {
+			h[0] = weightedEntry[T]{key: key, x: x}
+			heap.Fix(&h, 0)
+		}
+	})
+	ret := make([]T, len(h))
+	for i := range h {
+		ret[i] = h[i].x
+	}
+	return ret, err
+}
+
+type bernoulliStream[T any] struct {
+	s   Stream[T]
+	p   float64
+	rng *rand.Rand
+}
+
+func (s *bernoulliStream[T]) Next() (T, error) {
+	for {
+		x, err := s.s.Next()
+		if err != nil {
+			return *new(T), err
+		}
+		if randFloat64(s.rng) < s.p {
+			return x, nil
+		}
+	}
+}
+
+func (s *bernoulliStream[T]) Close() error {
+	return StreamClose(s.s)
+}
+
+// StreamBernoulliSample returns a stream that keeps every element of s
+// independently with probability p. Unlike the reservoir samplers, it is
+// lazy and works on endless streams, but the size of the sample is only
+// known on average.
+func StreamBernoulliSample[T any](s Stream[T], p float64, rng *rand.Rand) Stream[T] {
+	return &bernoulliStream[T]{s: s, p: p, rng: rng}
+}
+
+// StreamStratifiedSample drains s and returns a uniform random sample of
+// k elements for every stratum, the stratum of an element being given by
+// key. Strata with fewer than k elements are kept whole.
+func StreamStratifiedSample[T any, K comparable](s Stream[T], key func(T) K, k int, rng *rand.Rand) (map[K][]T, error) {
+	strata := make(map[K]*reservoir[T])
+	err := StreamForEach(s, func(x T) {
+		kx := key(x)
+		r, ok := strata[kx]
+		if !ok {
+			r = &reservoir[T]{k: k}
+			strata[kx] = r
+		}
+		r.offer(x, rng)
+	})
+	ret := make(map[K][]T, len(strata))
+	for kx, r := range strata {
+		ret[kx] = r.xs
+	}
+	return ret, err
+}
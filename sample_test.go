@@ -0,0 +1,85 @@
+package vino_test
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	. "github.com/humbornjo/vino"
+	"github.com/stretchr/testify/assert"
+)
+
+func seeded() *rand.Rand {
+	return rand.New(rand.NewPCG(114, 514))
+}
+
+func TestStreamReservoirSample(t *testing.T) {
+	sample, err := StreamReservoirSample(rangeStream(1000), 10, seeded())
+	assert.NoError(t, err)
+	assert.Len(t, sample, 10)
+	assert.Len(t, SliceUnique(sample), 10)
+
+	again, err := StreamReservoirSample(rangeStream(1000), 10, seeded())
+	assert.NoError(t, err)
+	assert.Equal(t, sample, again)
+
+	sample, err = StreamReservoirSample(rangeStream(3), 10, seeded())
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 1, 2}, sample)
+
+	// Every element should be picked about as often as any other.
+	counts := make([]int, 10)
+	rng := seeded()
+	for range 2000 {
+		sample, _ := StreamReservoirSample(rangeStream(10), 1, rng)
+		counts[sample[0]]++
+	}
+	for _, c := range counts {
+		assert.InDelta(t, 200, c, 60)
+	}
+}
+
+func TestStreamWeightedSample(t *testing.T) {
+	weight := func(x int) float64 {
+		if x%2 == 0 {
+			return 0
+		}
+		return float64(x)
+	}
+	sample, err := StreamWeightedSample(rangeStream(100), 5, weight, seeded())
+	assert.NoError(t, err)
+	assert.Len(t, sample, 5)
+	for _, x := range sample {
+		assert.Equal(t, 1, x%2)
+	}
+
+	// A heavy element is picked far more often than a light one.
+	heavy := 0
+	rng := seeded()
+	for range 1000 {
+		sample, _ := StreamWeightedSample(streamOf(1, 9), 1, func(x int) float64 { return float64(x) }, rng)
+		if sample[0] == 9 {
+			heavy++
+		}
+	}
+	assert.InDelta(t, 900, heavy, 50)
+}
+
+func TestStreamBernoulliSample(t *testing.T) {
+	sample, err := StreamCollect(StreamBernoulliSample(rangeStream(10000), 0.1, seeded()))
+	assert.NoError(t, err)
+	assert.InDelta(t, 1000, len(sample), 100)
+	assert.True(t, slices.IsSorted(sample))
+}
+
+func TestStreamStratifiedSample(t *testing.T) {
+	strata, err := StreamStratifiedSample(rangeStream(100), func(x int) int { return x % 3 }, 4, seeded())
+	assert.NoError(t, err)
+	assert.Len(t, strata, 3)
+	for k, xs := range strata {
+		assert.Len(t, xs, 4)
+		for _, x := range xs {
+			assert.Equal(t, k, x%3)
+		}
+	}
+}
@@ -0,0 +1,535 @@
+package vino
+
+import (
+	"cmp"
+	"container/heap"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"hash/fnv"
+	"maps"
+	"math"
+	"math/bits"
+	"slices"
+)
+
+// Sketch is implemented by the probabilistic summaries of this package,
+// which answer questions about a huge number of elements approximately,
+// in a small and fixed amount of memory. A sketch is fed one element at a
+// time, sketches built with the same parameters over different shards can
+// be merged, and they can be serialized to bytes with MarshalBinary.
+type Sketch[T any] interface {
+	Add(x T)
+}
+
+type sketchStream[T any] struct {
+	s        Stream[T]
+	sketches []Sketch[T]
+}
+
+func (s *sketchStream[T]) Next() (T, error) {
+	x, err := s.s.Next()
+	if err != nil {
+		return x, err
+	}
+	for _, sketch := range s.sketches {
+		sketch.Add(x)
+	}
+	return x, nil
+}
+
+func (s *sketchStream[T]) Close() error {
+	return StreamClose(s.s)
+}
+
+// StreamSketch returns a stream that yields the elements of s unchanged
+// while adding each of them to every one of sketches, so that several
+// sketches can be computed in a single pass alongside other processing.
+//
+// Example:
+//
+//	users := NewHyperLogLog(14, HashString)
+//	pages := NewTopK[string](100)
+//	n, err := StreamCount(StreamSketch(visits, users, pages))
+func StreamSketch[T any](s Stream[T], sketches ...Sketch[T]) Stream[T] {
+	return &sketchStream[T]{s: s, sketches: sketches}
+}
+
+// HashBytes is a 64-bit hash of b that is stable across processes and
+// machines, so that sketches built from it can be merged and serialized.
+// It is FNV-1a followed by the finalizer of MurmurHash3, which spreads
+// the bits well enough for the sketches of this package.
+func HashBytes(b []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(b)
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+// HashString is HashBytes for a string.
+func HashString(s string) uint64 {
+	return HashBytes([]byte(s))
+}
+
+// Every serialized sketch starts with a byte identifying its kind.
+const (
+	sketchHyperLogLog byte = 'H'
+	sketchCountMin    byte = 'M'
+	sketchTopK        byte = 'K'
+	sketchQuantile    byte = 'Q'
+)
+
+// sketchReader decodes the varints of a serialized sketch, remembering the
+// first failure so that it only needs to be checked once at the end.
+type sketchReader struct {
+	buf []byte
+	err error
+}
+
+func newSketchReader(kind byte, data []byte) *sketchReader {
+	if len(data) == 0 || data[0] != kind {
+		return &sketchReader{err: errors.New("sketch kind mismatch")}
+	}
+	return &sketchReader{buf: data[1:]}
+}
+
+func (r *sketchReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	x, size := binary.Uvarint(r.buf)
+	if size <= 0 {
+		r.err = errors.New("sketch is malformed")
+		return 0
+	}
+	r.buf = r.buf[size:]
+	return x
+}
+
+func (r *sketchReader) varint() int64 {
+	if r.err != nil {
+		return 0
+	}
+	x, size := binary.Varint(r.buf)
+	if size <= 0 {
+		r.err = errors.New("sketch is malformed")
+		return 0
+	}
+	r.buf = r.buf[size:]
+	return x
+}
+
+func (r *sketchReader) bytes(n uint64) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if uint64(len(r.buf)) < n {
+		r.err = errors.New("sketch is malformed")
+		return nil
+	}
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b
+}
+
+func (r *sketchReader) close() error {
+	if r.err == nil && len(r.buf) > 0 {
+		r.err = errors.New("sketch is malformed")
+	}
+	return r.err
+}
+
+// ------------------------------------------------------------------------
+//  HyperLogLog
+// ------------------------------------------------------------------------
+
+// HyperLogLog estimates the number of distinct elements it was fed. With
+// a precision p it uses 2^p bytes of memory and its relative standard
+// error is about 1.04/sqrt(2^p), that is 0.81% for the common p = 14.
+type HyperLogLog[T any] struct {
+	p    uint8
+	regs []uint8
+	hash func(T) uint64
+}
+
+// NewHyperLogLog returns an empty HyperLogLog with precision p, which is
+// clamped to [4, 18], hashing its elements with hash. Sketches can only
+// be merged or restored if they share both the precision and the hash.
+func NewHyperLogLog[T any](p uint8, hash func(T) uint64) *HyperLogLog[T] {
+	p = min(max(p, 4), 18)
+	return &HyperLogLog[T]{p: p, regs: make([]uint8, 1<<p), hash: hash}
+}
+
+// Add records x.
+func (h *HyperLogLog[T]) Add(x T) {
+	v := h.hash(x)
+	i := v >> (64 - h.p)
+	rho := uint8(bits.LeadingZeros64(v<<h.p|1<<(h.p-1))) + 1
+	h.regs[i] = max(h.regs[i], rho)
+}
+
+// Count returns the estimated number of distinct elements added so far.
+func (h *HyperLogLog[T]) Count() uint64 {
+	m := float64(len(h.regs))
+	sum, zeros := 0.0, 0
+	for _, r := range h.regs {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+	estimate := 0.7213 / (1 + 1.079/m) * m * m / sum
+	if estimate <= 2.5*m && zeros > 0 {
+		// Linear counting is more accurate for small cardinalities.
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return uint64(estimate + 0.5)
+}
+
+// Merge folds o into h, so that h estimates the distinct elements added to
+// either of them.
+func (h *HyperLogLog[T]) Merge(o *HyperLogLog[T]) error {
+	if h.p != o.p {
+		return errors.New("hyperloglog precision mismatch")
+	}
+	for i, r := range o.regs {
+		h.regs[i] = max(h.regs[i], r)
+	}
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (h *HyperLogLog[T]) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, 0, 2+len(h.regs))
+	buf = append(buf, sketchHyperLogLog, h.p)
+	return append(buf, h.regs...), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. h must have been
+// created with the hash the data was produced with.
+func (h *HyperLogLog[T]) UnmarshalBinary(data []byte) error {
+	if len(data) < 2 || data[0] != sketchHyperLogLog {
+		return errors.New("sketch kind mismatch")
+	}
+	p := data[1]
+	if p < 4 || p > 18 || len(data)-2 != 1<<p {
+		return errors.New("sketch is malformed")
+	}
+	h.p, h.regs = p, slices.Clone(data[2:])
+	return nil
+}
+
+// ------------------------------------------------------------------------
+//  Count-Min Sketch
+// ------------------------------------------------------------------------
+
+// CountMinSketch estimates how many times each element was added. An
+// estimate is never below the true count, and with a probability of at
+// least 1-delta it exceeds it by at most epsilon times the total count.
+type CountMinSketch[T any] struct {
+	width  uint64
+	depth  uint64
+	counts []uint64
+	total  uint64
+	hash   func(T) uint64
+}
+
+// NewCountMinSketch returns an empty CountMinSketch with the given error
+// bounds, hashing its elements with hash. It uses ceil(e/epsilon) *
+// ceil(ln(1/delta)) counters. Sketches can only be merged or restored if
+// they share both the bounds and the hash.
+func NewCountMinSketch[T any](epsilon float64, delta float64, hash func(T) uint64) (*CountMinSketch[T], error) {
+	if epsilon <= 0 || epsilon >= 1 || delta <= 0 || delta >= 1 {
+		return nil, errors.New("count-min sketch bounds must be in (0, 1)")
+	}
+	width := uint64(math.Ceil(math.E / epsilon))
+	depth := uint64(math.Ceil(math.Log(1 / delta)))
+	return &CountMinSketch[T]{width: width, depth: depth, counts: make([]uint64, width*depth), hash: hash}, nil
+}
+
+// cell returns the index of the counter of the hash v in the i-th row.
+// The rows derive their hashes from v by double hashing.
+func (s *CountMinSketch[T]) cell(v uint64, i uint64) uint64 {
+	h1, h2 := v&math.MaxUint32, v>>32|1
+	return i*s.width + (h1+i*h2)%s.width
+}
+
+// Add records one occurrence of x.
+func (s *CountMinSketch[T]) Add(x T) {
+	s.AddN(x, 1)
+}
+
+// AddN records n occurrences of x.
+func (s *CountMinSketch[T]) AddN(x T, n uint64) {
+	v := s.hash(x)
+	for i := range s.depth {
+		s.counts[s.cell(v, i)] += n
+	}
+	s.total += n
+}
+
+// Estimate returns the estimated number of occurrences of x.
+func (s *CountMinSketch[T]) Estimate(x T) uint64 {
+	v := s.hash(x)
+	ret := uint64(math.MaxUint64)
+	for i := range s.depth {
+		ret = min(ret, s.counts[s.cell(v, i)])
+	}
+	return ret
+}
+
+// Total returns the number of occurrences added so far.
+func (s *CountMinSketch[T]) Total() uint64 {
+	return s.total
+}
+
+// Merge folds o into s, so that s counts the occurrences added to either
+// of them.
+func (s *CountMinSketch[T]) Merge(o *CountMinSketch[T]) error {
+	if s.width != o.width || s.depth != o.depth {
+		return errors.New("count-min sketch dimension mismatch")
+	}
+	for i, c := range o.counts {
+		s.counts[i] += c
+	}
+	s.total += o.total
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (s *CountMinSketch[T]) MarshalBinary() ([]byte, error) {
+	buf := []byte{sketchCountMin}
+	buf = binary.AppendUvarint(buf, s.width)
+	buf = binary.AppendUvarint(buf, s.depth)
+	buf = binary.AppendUvarint(buf, s.total)
+	for _, c := range s.counts {
+		buf = binary.AppendUvarint(buf, c)
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. s must have been
+// created with the hash the data was produced with.
+func (s *CountMinSketch[T]) UnmarshalBinary(data []byte) error {
+	r := newSketchReader(sketchCountMin, data)
+	width, depth, total := r.uvarint(), r.uvarint(), r.uvarint()
+	// Every counter takes at least a byte, which bounds the allocation
+	// without multiplying width by depth, as that could overflow.
+	if r.err == nil && (width == 0 || depth == 0 || depth > uint64(len(r.buf))/width) {
+		r.err = errors.New("sketch is malformed")
+	}
+	if r.err != nil {
+		return r.err
+	}
+	counts := make([]uint64, 0, width*depth)
+	for r.err == nil && uint64(len(counts)) < width*depth {
+		counts = append(counts, r.uvarint())
+	}
+	if err := r.close(); err != nil {
+		return err
+	}
+	s.width, s.depth, s.total, s.counts = width, depth, total, counts
+	return nil
+}
+
+// ------------------------------------------------------------------------
+//  Top-K
+// ------------------------------------------------------------------------
+
+// TopKEntry is an element tracked by a TopK. Its true count is between
+// Count-Error and Count.
+type TopKEntry[T comparable] struct {
+	Value T
+	Count uint64
+	Error uint64
+}
+
+// TopK tracks the most frequent elements with the Space-Saving algorithm,
+// keeping a fixed number of counters. Any element occurring more than
+// N/capacity times out of N is guaranteed to be tracked.
+type TopK[T comparable] struct {
+	capacity int
+	h        topKHeap[T]
+}
+
+// topKHeap is a min-heap of the tracked elements by count, indexed by
+// element.
+type topKHeap[T comparable] struct {
+	entries []*TopKEntry[T]
+	index   map[T]int
+}
+
+func (h *topKHeap[T]) Len() int {
+	return len(h.entries)
+}
+
+func (h *topKHeap[T]) Less(i, j int) bool {
+	return h.entries[i].Count < h.entries[j].Count
+}
+
+func (h *topKHeap[T]) Swap(i, j int) {
+	h.entries[i], h.entries[j] = h.entries[j], h.entries[i]
+	h.index[h.entries[i].Value] = i
+	h.index[h.entries[j].Value] = j
+}
+
+func (h *topKHeap[T]) Push(x any) {
+	e := x.(*TopKEntry[T])
+	h.index[e.Value] = len(h.entries)
+	h.entries = append(h.entries, e)
+}
+
+func (h *topKHeap[T]) Pop() any {
+	e := h.entries[len(h.entries)-1]
+	h.entries = h.entries[:len(h.entries)-1]
+	delete(h.index, e.Value)
+	return e
+}
+
+// reset replaces the tracked elements with entries.
+func (h *topKHeap[T]) reset(entries []*TopKEntry[T]) {
+	h.entries, h.index = nil, make(map[T]int, len(entries))
+	for _, e := range entries {
+		heap.Push(h, e)
+	}
+}
+
+// NewTopK returns an empty TopK keeping capacity counters, which should
+// be comfortably larger than the number of top elements to be queried.
+func NewTopK[T comparable](capacity int) *TopK[T] {
+	capacity = max(capacity, 1)
+	return &TopK[T]{capacity: capacity, h: topKHeap[T]{index: make(map[T]int, capacity)}}
+}
+
+// Add records one occurrence of x.
+func (t *TopK[T]) Add(x T) {
+	t.AddN(x, 1)
+}
+
+// AddN records n occurrences of x. If x is not tracked and all counters
+// are taken, x replaces the least frequent element and inherits its count
+// as its error.
+func (t *TopK[T]) AddN(x T, n uint64) {
+	if i, ok := t.h.index[x]; ok {
+		t.h.entries[i].Count += n
+		heap.Fix(&t.h, i)
+		return
+	}
+	if len(t.h.entries) < t.capacity {
+		heap.Push(&t.h, &TopKEntry[T]{Value: x, Count: n})
+		return
+	}
+	e := t.h.entries[0]
+	delete(t.h.index, e.Value)
+	e.Value, e.Error = x, e.Count
+	e.Count += n
+	t.h.index[x] = 0
+	heap.Fix(&t.h, 0)
+}
+
+// Top returns the k most frequent elements, from the most to the least
+// frequent.
+func (t *TopK[T]) Top(k int) []TopKEntry[T] {
+	ret := make([]TopKEntry[T], len(t.h.entries))
+	for i, e := range t.h.entries {
+		ret[i] = *e
+	}
+	slices.SortFunc(ret, func(a, b TopKEntry[T]) int {
+		return cmp.Compare(b.Count, a.Count)
+	})
+	return ret[:min(k, len(ret))]
+}
+
+// floor is the largest count an element that is not tracked may have.
+func (t *TopK[T]) floor() uint64 {
+	if len(t.h.entries) < t.capacity {
+		return 0
+	}
+	return t.h.entries[0].Count
+}
+
+// Merge folds o into t, so that t tracks the most frequent elements added
+// to either of them. An element tracked by only one of them is credited
+// with the largest count it may have in the other.
+func (t *TopK[T]) Merge(o *TopK[T]) error {
+	if t.capacity != o.capacity {
+		return errors.New("top-k capacity mismatch")
+	}
+	tf, of := t.floor(), o.floor()
+	merged := make(map[T]*TopKEntry[T], len(t.h.entries)+len(o.h.entries))
+	for _, e := range t.h.entries {
+		merged[e.Value] = &TopKEntry[T]{Value: e.Value, Count: e.Count + of, Error: e.Error + of}
+		if i, ok := o.h.index[e.Value]; ok {
+			merged[e.Value].Count = e.Count + o.h.entries[i].Count
+			merged[e.Value].Error = e.Error + o.h.entries[i].Error
+		}
+	}
+	for _, e := range o.h.entries {
+		if _, ok := merged[e.Value]; !ok {
+			merged[e.Value] = &TopKEntry[T]{Value: e.Value, Count: e.Count + tf, Error: e.Error + tf}
+		}
+	}
+
+	entries := slices.Collect(maps.Values(merged))
+	slices.SortFunc(entries, func(a, b *TopKEntry[T]) int {
+		return cmp.Compare(b.Count, a.Count)
+	})
+	t.h.reset(entries[:min(t.capacity, len(entries))])
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler. The tracked elements
+// are encoded as JSON, so T must survive a round trip through it.
+func (t *TopK[T]) MarshalBinary() ([]byte, error) {
+	buf := []byte{sketchTopK}
+	buf = binary.AppendUvarint(buf, uint64(t.capacity))
+	buf = binary.AppendUvarint(buf, uint64(len(t.h.entries)))
+	for _, e := range t.h.entries {
+		value, err := json.Marshal(e.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf = binary.AppendUvarint(buf, e.Count)
+		buf = binary.AppendUvarint(buf, e.Error)
+		buf = binary.AppendUvarint(buf, uint64(len(value)))
+		buf = append(buf, value...)
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (t *TopK[T]) UnmarshalBinary(data []byte) error {
+	r := newSketchReader(sketchTopK, data)
+	capacity, n := r.uvarint(), r.uvarint()
+	if r.err == nil && (capacity == 0 || capacity > math.MaxInt || n > capacity || n > uint64(len(r.buf))) {
+		r.err = errors.New("sketch is malformed")
+	}
+	if r.err != nil {
+		return r.err
+	}
+	entries := make([]*TopKEntry[T], 0, n)
+	seen := make(map[T]struct{}, n)
+	for r.err == nil && uint64(len(entries)) < n {
+		e := &TopKEntry[T]{Count: r.uvarint(), Error: r.uvarint()}
+		value := r.bytes(r.uvarint())
+		if r.err == nil {
+			r.err = json.Unmarshal(value, &e.Value)
+		}
+		if _, ok := seen[e.Value]; ok && r.err == nil {
+			r.err = errors.New("sketch is malformed")
+		}
+		seen[e.Value] = struct{}{}
+		entries = append(entries, e)
+	}
+	if err := r.close(); err != nil {
+		return err
+	}
+	t.capacity = int(capacity)
+	t.h.reset(entries)
+	return nil
+}
@@ -0,0 +1,155 @@
+package vino_test
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"testing"
+
+	. "github.com/humbornjo/vino"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHyperLogLog(t *testing.T) {
+	a := NewHyperLogLog(14, HashString)
+	b := NewHyperLogLog(14, HashString)
+	for i := range 60000 {
+		a.Add(strconv.Itoa(i))
+		// b shares half of its elements with a.
+		b.Add(strconv.Itoa(i + 30000))
+	}
+	assert.InEpsilon(t, 60000, float64(a.Count()), 0.03)
+
+	small := NewHyperLogLog(14, HashString)
+	for _, x := range []string{"a", "b", "c", "a", "b"} {
+		small.Add(x)
+	}
+	assert.Equal(t, uint64(3), small.Count())
+
+	data, err := b.MarshalBinary()
+	assert.NoError(t, err)
+	restored := NewHyperLogLog(4, HashString)
+	assert.NoError(t, restored.UnmarshalBinary(data))
+	assert.Equal(t, b.Count(), restored.Count())
+
+	assert.NoError(t, a.Merge(restored))
+	assert.InEpsilon(t, 90000, float64(a.Count()), 0.03)
+
+	assert.Error(t, a.Merge(NewHyperLogLog(10, HashString)))
+	assert.Error(t, restored.UnmarshalBinary(data[:len(data)-1]))
+}
+
+func TestCountMinSketch(t *testing.T) {
+	_, err := NewCountMinSketch(0, 0.01, HashString)
+	assert.Error(t, err)
+
+	a, err := NewCountMinSketch(0.001, 0.01, HashString)
+	assert.NoError(t, err)
+	b, err := NewCountMinSketch(0.001, 0.01, HashString)
+	assert.NoError(t, err)
+
+	for i := range 10000 {
+		a.Add(strconv.Itoa(i % 1000))
+	}
+	a.AddN("hot", 5000)
+	b.AddN("hot", 2000)
+
+	assert.Equal(t, uint64(15000), a.Total())
+	assert.GreaterOrEqual(t, a.Estimate("hot"), uint64(5000))
+	assert.LessOrEqual(t, a.Estimate("hot"), uint64(5000+15))
+	assert.GreaterOrEqual(t, a.Estimate("7"), uint64(10))
+
+	data, err := b.MarshalBinary()
+	assert.NoError(t, err)
+	restored, _ := NewCountMinSketch(0.5, 0.5, HashString)
+	assert.NoError(t, restored.UnmarshalBinary(data))
+	assert.Equal(t, uint64(2000), restored.Estimate("hot"))
+
+	assert.NoError(t, a.Merge(restored))
+	assert.Equal(t, uint64(17000), a.Total())
+	assert.GreaterOrEqual(t, a.Estimate("hot"), uint64(7000))
+
+	other, _ := NewCountMinSketch(0.01, 0.01, HashString)
+	assert.Error(t, a.Merge(other))
+	assert.Error(t, restored.UnmarshalBinary([]byte("H")))
+
+	// Headers claiming more counters than there are bytes, directly or by
+	// overflowing width*depth, must not allocate them.
+	huge := binary.AppendUvarint([]byte("M"), 1<<40)
+	huge = binary.AppendUvarint(huge, 1)
+	huge = binary.AppendUvarint(huge, 0)
+	assert.EqualError(t, restored.UnmarshalBinary(huge), "sketch is malformed")
+	overflow := binary.AppendUvarint([]byte("M"), 1<<32)
+	overflow = binary.AppendUvarint(overflow, 1<<32)
+	overflow = binary.AppendUvarint(overflow, 0)
+	overflow = append(overflow, 1, 2, 3)
+	assert.EqualError(t, restored.UnmarshalBinary(overflow), "sketch is malformed")
+	assert.Equal(t, uint64(2000), restored.Estimate("hot"))
+}
+
+func TestTopK(t *testing.T) {
+	a := NewTopK[string](16)
+	for i := range 100 {
+		a.Add(fmt.Sprint("noise", i))
+		if i%2 == 0 {
+			a.Add("x")
+		}
+		if i%4 == 0 {
+			a.Add("y")
+		}
+	}
+
+	top := a.Top(2)
+	assert.Equal(t, "x", top[0].Value)
+	assert.Equal(t, "y", top[1].Value)
+	for _, e := range top {
+		assert.LessOrEqual(t, e.Count-e.Error, map[string]uint64{"x": 50, "y": 25}[e.Value])
+	}
+
+	b := NewTopK[string](16)
+	b.AddN("z", 80)
+	b.AddN("y", 10)
+
+	data, err := b.MarshalBinary()
+	assert.NoError(t, err)
+	restored := NewTopK[string](1)
+	assert.NoError(t, restored.UnmarshalBinary(data))
+	assert.Equal(t, b.Top(10), restored.Top(10))
+
+	assert.NoError(t, a.Merge(restored))
+	top = a.Top(3)
+	assert.Equal(t, []string{"z", "x", "y"}, []string{top[0].Value, top[1].Value, top[2].Value})
+	assert.Len(t, a.Top(20), 16)
+
+	assert.Error(t, a.Merge(NewTopK[string](4)))
+
+	huge := binary.AppendUvarint([]byte("K"), 0)
+	huge = binary.AppendUvarint(huge, 1<<60)
+	assert.EqualError(t, restored.UnmarshalBinary(huge), "sketch is malformed")
+	huge = binary.AppendUvarint([]byte("K"), 1<<63)
+	huge = binary.AppendUvarint(huge, 0)
+	assert.EqualError(t, restored.UnmarshalBinary(huge), "sketch is malformed")
+
+	// The same value tracked twice would corrupt the index.
+	dup := binary.AppendUvarint([]byte("K"), 4)
+	dup = binary.AppendUvarint(dup, 2)
+	for range 2 {
+		dup = binary.AppendUvarint(dup, 3)
+		dup = binary.AppendUvarint(dup, 0)
+		dup = binary.AppendUvarint(dup, 3)
+		dup = append(dup, `"a"`...)
+	}
+	assert.EqualError(t, restored.UnmarshalBinary(dup), "sketch is malformed")
+	assert.Equal(t, b.Top(10), restored.Top(10))
+}
+
+func TestStreamSketch(t *testing.T) {
+	hll := NewHyperLogLog(12, HashString)
+	topk := NewTopK[string](8)
+
+	n, err := StreamCount(StreamSketch(streamOf("a", "b", "a", "c", "a"), hll, topk))
+	assert.NoError(t, err)
+	assert.Equal(t, 5, n)
+	assert.Equal(t, uint64(3), hll.Count())
+	assert.Equal(t, []TopKEntry[string]{{Value: "a", Count: 3}}, topk.Top(1))
+}
@@ -0,0 +1,417 @@
+package vino
+
+import "errors"
+
+// ------------------------------------------------------------------------
+//  Stream Adapters
+// ------------------------------------------------------------------------
+//
+// The adapters below are lazy: none of them pulls from the upstream
+// stream until Next is called on the returned stream. Any error returned
+// by the upstream Next is handed back to the caller untouched, which
+// means an exhausted upstream yields an exhausted adapter. Every adapter
+// implements io.Closer and forwards Close to the streams it wraps.
+
+// Pair holds two values of possibly different types. It is the element
+// type produced by StreamZip and StreamEnumerate.
+type Pair[L any, R any] struct {
+	Left  L
+	Right R
+}
+
+type mapStream[T any, U any] struct {
+	s  Stream[T]
+	fn func(T) U
+}
+
+func (s *mapStream[T, U]) Next() (U, error) {
+	x, err := s.s.Next()
+	if err != nil {
+		return *new(U), err
+	}
+	return s.fn(x), nil
+}
+
+func (s *mapStream[T, U]) Close() error {
+	return StreamClose(s.s)
+}
+
+// StreamMap returns a stream that yields fn(x) for every element x of s.
+func StreamMap[T any, U any](s Stream[T], fn func(T) U) Stream[U] {
+	return &mapStream[T, U]{s: s, fn: fn}
+}
+
+type filterStream[T any] struct {
+	s      Stream[T]
+	filter FilterFunc[T]
+}
+
+func (s *filterStream[T]) Next() (T, error) {
+	for {
+		x, err := s.s.Next()
+		if err != nil {
+			return *new(T), err
+		}
+		if s.filter.Test(x) {
+			continue
+		}
+		return x, nil
+	}
+}
+
+func (s *filterStream[T]) Close() error {
+	return StreamClose(s.s)
+}
+
+// StreamFilter returns a stream that yields only the elements of s for
+// which filter returns false, following the same convention as
+// FunctionalFilter. A nil filter keeps everything.
+func StreamFilter[T any](s Stream[T], filter FilterFunc[T]) Stream[T] {
+	return &filterStream[T]{s: s, filter: filter}
+}
+
+type flatMapStream[T any, U any] struct {
+	s   Stream[T]
+	fn  func(T) Stream[U]
+	cur Stream[U]
+}
+
+func (s *flatMapStream[T, U]) Next() (U, error) {
+	for {
+		if s.cur != nil {
+			x, err := s.cur.Next()
+			if err == nil {
+				return x, nil
+			}
+			if !errors.Is(err, ErrStreamExhausted) {
+				return *new(U), err
+			}
+			StreamClose(s.cur)
+			s.cur = nil
+		}
+		x, err := s.s.Next()
+		if err != nil {
+			return *new(U), err
+		}
+		s.cur = s.fn(x)
+	}
+}
+
+func (s *flatMapStream[T, U]) Close() error {
+	var err error
+	if s.cur != nil {
+		err = StreamClose(s.cur)
+	}
+	return errors.Join(err, StreamClose(s.s))
+}
+
+// StreamFlatMap returns a stream that maps every element of s to a stream
+// with fn and yields the elements of those streams one after another. An
+// inner stream is drained until it is exhausted, after which the next
+// element of s is pulled. Any other error from an inner stream is
+// returned as is.
+func StreamFlatMap[T any, U any](s Stream[T], fn func(T) Stream[U]) Stream[U] {
+	return &flatMapStream[T, U]{s: s, fn: fn}
+}
+
+type takeStream[T any] struct {
+	s Stream[T]
+	n int
+}
+
+func (s *takeStream[T]) Next() (T, error) {
+	if s.n <= 0 {
+		return *new(T), ErrStreamExhausted
+	}
+	x, err := s.s.Next()
+	if err != nil {
+		return *new(T), err
+	}
+	s.n--
+	return x, nil
+}
+
+func (s *takeStream[T]) Close() error {
+	return StreamClose(s.s)
+}
+
+// StreamTake returns a stream that yields at most the first n elements
+// of s.
+func StreamTake[T any](s Stream[T], n int) Stream[T] {
+	return &takeStream[T]{s: s, n: n}
+}
+
+type skipStream[T any] struct {
+	s Stream[T]
+	n int
+}
+
+func (s *skipStream[T]) Next() (T, error) {
+	for ; s.n > 0; s.n-- {
+		if _, err := s.s.Next(); err != nil {
+			return *new(T), err
+		}
+	}
+	return s.s.Next()
+}
+
+func (s *skipStream[T]) Close() error {
+	return StreamClose(s.s)
+}
+
+// StreamSkip returns a stream that discards the first n elements of s
+// and yields the rest.
+func StreamSkip[T any](s Stream[T], n int) Stream[T] {
+	return &skipStream[T]{s: s, n: n}
+}
+
+type takeWhileStream[T any] struct {
+	s    Stream[T]
+	pred func(T) bool
+	done bool
+}
+
+func (s *takeWhileStream[T]) Next() (T, error) {
+	if s.done {
+		return *new(T), ErrStreamExhausted
+	}
+	x, err := s.s.Next()
+	if err != nil {
+		return *new(T), err
+	}
+	if !s.pred(x) {
+		s.done = true
+		return *new(T), ErrStreamExhausted
+	}
+	return x, nil
+}
+
+func (s *takeWhileStream[T]) Close() error {
+	return StreamClose(s.s)
+}
+
+// StreamTakeWhile returns a stream that yields elements of s as long as
+// pred returns true. The first element rejected by pred is consumed and
+// ends the stream.
+func StreamTakeWhile[T any](s Stream[T], pred func(T) bool) Stream[T] {
+	return &takeWhileStream[T]{s: s, pred: pred}
+}
+
+type chainStream[T any] struct {
+	ss []Stream[T]
+}
+
+func (s *chainStream[T]) Next() (T, error) {
+	for len(s.ss) > 0 {
+		x, err := s.ss[0].Next()
+		if err == nil {
+			return x, nil
+		}
+		if !errors.Is(err, ErrStreamExhausted) {
+			return *new(T), err
+		}
+		StreamClose(s.ss[0])
+		s.ss = s.ss[1:]
+	}
+	return *new(T), ErrStreamExhausted
+}
+
+func (s *chainStream[T]) Close() error {
+	errs := make([]error, 0, len(s.ss))
+	for _, ss := range s.ss {
+		errs = append(errs, StreamClose(ss))
+	}
+	return errors.Join(errs...)
+}
+
+// StreamChain returns a stream that yields every element of the first
+// stream, then every element of the second one, and so on. A stream
+// failing with anything other than ErrStreamExhausted stops the chain
+// from moving on to the next one. Each stream is closed as soon as it is
+// exhausted, and closing the chain closes the ones that are left.
+func StreamChain[T any](ss ...Stream[T]) Stream[T] {
+	return &chainStream[T]{ss: ss}
+}
+
+type zipStream[L any, R any] struct {
+	l Stream[L]
+	r Stream[R]
+}
+
+func (s *zipStream[L, R]) Next() (Pair[L, R], error) {
+	l, err := s.l.Next()
+	if err != nil {
+		return Pair[L, R]{}, err
+	}
+	r, err := s.r.Next()
+	if err != nil {
+		return Pair[L, R]{}, err
+	}
+	return Pair[L, R]{Left: l, Right: r}, nil
+}
+
+func (s *zipStream[L, R]) Close() error {
+	return errors.Join(StreamClose(s.l), StreamClose(s.r))
+}
+
+// StreamZip returns a stream that pairs up the elements of l and r. The
+// resulting stream ends as soon as either of them ends.
+func StreamZip[L any, R any](l Stream[L], r Stream[R]) Stream[Pair[L, R]] {
+	return &zipStream[L, R]{l: l, r: r}
+}
+
+type enumerateStream[T any] struct {
+	s   Stream[T]
+	idx int
+}
+
+func (s *enumerateStream[T]) Next() (Pair[int, T], error) {
+	x, err := s.s.Next()
+	if err != nil {
+		return Pair[int, T]{}, err
+	}
+	s.idx++
+	return Pair[int, T]{Left: s.idx - 1, Right: x}, nil
+}
+
+func (s *enumerateStream[T]) Close() error {
+	return StreamClose(s.s)
+}
+
+// StreamEnumerate returns a stream that pairs every element of s with its
+// zero-based position.
+func StreamEnumerate[T any](s Stream[T]) Stream[Pair[int, T]] {
+	return &enumerateStream[T]{s: s}
+}
+
+// ------------------------------------------------------------------------
+//  Fallible Stream Adapters
+// ------------------------------------------------------------------------
+//
+// Unlike the adapters above, the fallible ones wrap every failure, be it
+// from the upstream stream or from the user function, in a *StreamError
+// that records the operation and the position of the offending element.
+// ErrStreamExhausted is still passed through as is.
+
+type tryMapStream[T any, U any] struct {
+	s   Stream[T]
+	fn  func(T) (U, error)
+	idx int
+}
+
+func (s *tryMapStream[T, U]) Next() (U, error) {
+	idx := s.idx
+	x, err := s.s.Next()
+	if err != nil {
+		return *new(U), wrapStreamError("map", idx, err)
+	}
+	s.idx++
+	y, err := s.fn(x)
+	if err != nil {
+		return *new(U), wrapStreamError("map", idx, err)
+	}
+	return y, nil
+}
+
+func (s *tryMapStream[T, U]) Close() error {
+	return StreamClose(s.s)
+}
+
+// StreamTryMap is like StreamMap but fn may fail. A failing element is
+// reported through Next and the stream can keep being pulled afterwards.
+func StreamTryMap[T any, U any](s Stream[T], fn func(T) (U, error)) Stream[U] {
+	return &tryMapStream[T, U]{s: s, fn: fn}
+}
+
+type tryFilterStream[T any] struct {
+	s      Stream[T]
+	filter func(T) (bool, error)
+	idx    int
+}
+
+func (s *tryFilterStream[T]) Next() (T, error) {
+	for {
+		idx := s.idx
+		x, err := s.s.Next()
+		if err != nil {
+			return *new(T), wrapStreamError("filter", idx, err)
+		}
+		s.idx++
+		drop, err := s.filter(x)
+		if err != nil {
+			return *new(T), wrapStreamError("filter", idx, err)
+		}
+		if !drop {
+			return x, nil
+		}
+	}
+}
+
+func (s *tryFilterStream[T]) Close() error {
+	return StreamClose(s.s)
+}
+
+// StreamTryFilter is like StreamFilter but filter may fail. A failing
+// element is reported through Next and the stream can keep being pulled
+// afterwards.
+func StreamTryFilter[T any](s Stream[T], filter func(T) (bool, error)) Stream[T] {
+	return &tryFilterStream[T]{s: s, filter: filter}
+}
+
+func wrapStreamError(op string, idx int, err error) error {
+	if errors.Is(err, ErrStreamExhausted) {
+		return err
+	}
+	return &StreamError{Op: op, Index: idx, Err: err}
+}
+
+// ------------------------------------------------------------------------
+//  Stream Terminals
+// ------------------------------------------------------------------------
+//
+// The terminals drain a stream until it is exhausted, in which case they
+// report a nil error, or until it fails, in which case they stop and
+// return the failure along with what has been gathered so far. Closing
+// the stream is left to the caller.
+
+// StreamCollect drains s and returns its elements in a slice.
+func StreamCollect[T any](s Stream[T]) ([]T, error) {
+	ret := make([]T, 0)
+	err := StreamForEach(s, func(x T) {
+		ret = append(ret, x)
+	})
+	return ret, err
+}
+
+// StreamCount drains s and returns the number of elements it yielded.
+func StreamCount[T any](s Stream[T]) (int, error) {
+	n := 0
+	err := StreamForEach(s, func(_ T) {
+		n++
+	})
+	return n, err
+}
+
+// StreamForEach drains s and calls f for each element it yields.
+func StreamForEach[T any](s Stream[T], f func(T)) error {
+	for {
+		x, err := s.Next()
+		if errors.Is(err, ErrStreamExhausted) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		f(x)
+	}
+}
+
+// StreamReduce drains s and folds its elements into a single value,
+// starting from init and applying f to the accumulator and each element.
+func StreamReduce[T any, U any](s Stream[T], init U, f func(U, T) U) (U, error) {
+	acc := init
+	err := StreamForEach(s, func(x T) {
+		acc = f(acc, x)
+	})
+	return acc, err
+}
@@ -0,0 +1,223 @@
+package vino_test
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	. "github.com/humbornjo/vino"
+	"github.com/stretchr/testify/assert"
+)
+
+type testStream[T any] struct {
+	xs []T
+}
+
+func (s *testStream[T]) Next() (T, error) {
+	if len(s.xs) == 0 {
+		return *new(T), ErrStreamExhausted
+	}
+	x := s.xs[0]
+	s.xs = s.xs[1:]
+	return x, nil
+}
+
+func streamOf[T any](xs ...T) Stream[T] {
+	return &testStream[T]{xs: xs}
+}
+
+func TestStreamAdapters(t *testing.T) {
+	isOdd := func(x int) bool { return x%2 == 1 }
+
+	tests := []struct {
+		name string
+		s    Stream[int]
+		want []int
+	}{
+		{
+			"map",
+			StreamMap(streamOf(1, 2, 3), func(x int) int { return x * 10 }),
+			[]int{10, 20, 30},
+		},
+		{
+			"filter",
+			StreamFilter(streamOf(1, 2, 3, 4, 5), isOdd),
+			[]int{2, 4},
+		},
+		{
+			"flat map",
+			StreamFlatMap(streamOf(1, 2, 3), func(x int) Stream[int] {
+				return StreamTake(streamOf(x, x, x), x)
+			}),
+			[]int{1, 2, 2, 3, 3, 3},
+		},
+		{
+			"take",
+			StreamTake(streamOf(1, 2, 3, 4), 2),
+			[]int{1, 2},
+		},
+		{
+			"take more than available",
+			StreamTake(streamOf(1, 2), 5),
+			[]int{1, 2},
+		},
+		{
+			"skip",
+			StreamSkip(streamOf(1, 2, 3, 4), 3),
+			[]int{4},
+		},
+		{
+			"skip everything",
+			StreamSkip(streamOf(1, 2), 5),
+			[]int{},
+		},
+		{
+			"take while",
+			StreamTakeWhile(streamOf(1, 2, 3, 1), func(x int) bool { return x < 3 }),
+			[]int{1, 2},
+		},
+		{
+			"chain",
+			StreamChain(streamOf(1), streamOf[int](), streamOf(2, 3)),
+			[]int{1, 2, 3},
+		},
+		{
+			"chain nothing",
+			StreamChain[int](),
+			[]int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := StreamCollect(tt.s)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestStreamZipEnumerate(t *testing.T) {
+	zipped, err := StreamCollect(StreamZip(streamOf(1, 2, 3), streamOf("a", "b")))
+	assert.NoError(t, err)
+	assert.Equal(t, []Pair[int, string]{{1, "a"}, {2, "b"}}, zipped)
+
+	enumerated, err := StreamCollect(StreamEnumerate(streamOf("a", "b")))
+	assert.NoError(t, err)
+	assert.Equal(t, []Pair[int, string]{{0, "a"}, {1, "b"}}, enumerated)
+}
+
+func TestStreamTerminals(t *testing.T) {
+	n, err := StreamCount(streamOf(1, 2, 3, 4))
+	assert.NoError(t, err)
+	assert.Equal(t, 4, n)
+	n, err = StreamCount(streamOf[int]())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	sum, err := StreamReduce(streamOf(1, 2, 3, 4), 0, func(acc, x int) int { return acc + x })
+	assert.NoError(t, err)
+	assert.Equal(t, 10, sum)
+
+	joined, err := StreamReduce(streamOf(1, 2, 3), "", func(acc string, x int) string {
+		return acc + strconv.Itoa(x)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "123", joined)
+
+	seen := []int{}
+	err = StreamForEach(streamOf(3, 2, 1), func(x int) { seen = append(seen, x) })
+	assert.NoError(t, err)
+	assert.Equal(t, []int{3, 2, 1}, seen)
+}
+
+func TestStreamLaziness(t *testing.T) {
+	pulled := 0
+	s := StreamMap(streamOf(1, 2, 3, 4, 5), func(x int) int {
+		pulled++
+		return x
+	})
+	s = StreamTake(s, 2)
+	assert.Equal(t, 0, pulled)
+	got, err := StreamCollect(s)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, got)
+	assert.Equal(t, 2, pulled)
+}
+
+type failingStream struct {
+	n      int
+	err    error
+	closed bool
+}
+
+func (s *failingStream) Next() (int, error) {
+	if s.n == 0 {
+		return 0, s.err
+	}
+	s.n--
+	return s.n, nil
+}
+
+func (s *failingStream) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestStreamErrors(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	xs, err := StreamCollect(StreamMap(&failingStream{n: 2, err: errBoom}, strconv.Itoa))
+	assert.ErrorIs(t, err, errBoom)
+	assert.Equal(t, []string{"1", "0"}, xs)
+
+	// A chain must not skip over a failing stream.
+	_, err = StreamCollect(StreamChain(&failingStream{n: 1, err: errBoom}, streamOf(1)))
+	assert.ErrorIs(t, err, errBoom)
+
+	s := StreamTryMap(streamOf(1, 0, 2), func(x int) (int, error) {
+		if x == 0 {
+			return 0, errBoom
+		}
+		return 10 / x, nil
+	})
+	x, err := s.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, 10, x)
+	_, err = s.Next()
+	assert.ErrorIs(t, err, errBoom)
+	var serr *StreamError
+	if assert.ErrorAs(t, err, &serr) {
+		assert.Equal(t, "map", serr.Op)
+		assert.Equal(t, 1, serr.Index)
+	}
+	x, err = s.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, 5, x)
+	_, err = s.Next()
+	assert.ErrorIs(t, err, ErrStreamExhausted)
+
+	s = StreamTryFilter[int](&failingStream{n: 3, err: errBoom}, func(x int) (bool, error) {
+		return x == 1, nil
+	})
+	xs2, err := StreamCollect(s)
+	assert.Equal(t, []int{2, 0}, xs2)
+	if assert.ErrorAs(t, err, &serr) {
+		assert.Equal(t, "filter", serr.Op)
+		assert.Equal(t, 3, serr.Index)
+	}
+}
+
+func TestStreamClose(t *testing.T) {
+	a, b := &failingStream{err: ErrStreamExhausted}, &failingStream{n: 1, err: ErrStreamExhausted}
+	s := StreamTake(StreamChain[int](a, b), 1)
+	_, err := s.Next()
+	assert.NoError(t, err)
+	assert.True(t, a.closed)
+	assert.False(t, b.closed)
+
+	assert.NoError(t, StreamClose(s))
+	assert.True(t, b.closed)
+
+	assert.NoError(t, StreamClose(streamOf(1)))
+}
@@ -0,0 +1,357 @@
+package vino
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Window is a group of consecutive elements emitted by a windowing stage.
+// Start and End delimit the window in processing time. For time-based
+// windows they are the window bounds, End being exclusive; for count and
+// session windows they are the arrival times of the first and the last
+// element.
+type Window[T any] struct {
+	Start time.Time
+	End   time.Time
+	Items []T
+}
+
+// windower is the state machine behind a WindowSpec. Elements are fed to
+// add along with their arrival time, tick lets time-based windows close
+// without new elements, and flush hands out whatever is left at the end.
+type windower[T any] interface {
+	add(now time.Time, x T) []Window[T]
+	tick(now time.Time) []Window[T]
+	deadline() (time.Time, bool)
+	flush() []Window[T]
+}
+
+// WindowSpec describes how elements are grouped into windows. Specs are
+// created with TumblingWindow, SlidingWindow, TumblingTimeWindow,
+// SlidingTimeWindow and SessionWindow, and can be shared freely since each
+// stage builds its own state from them.
+type WindowSpec[T any] struct {
+	newWindower func() windower[T]
+}
+
+// ------------------------------------------------------------------------
+//  Count-Based Windows
+// ------------------------------------------------------------------------
+
+type timed[T any] struct {
+	at time.Time
+	x  T
+}
+
+func timedWindow[T any](xs []timed[T]) Window[T] {
+	items := make([]T, len(xs))
+	for i := range xs {
+		items[i] = xs[i].x
+	}
+	return Window[T]{Start: xs[0].at, End: xs[len(xs)-1].at, Items: items}
+}
+
+type countWindower[T any] struct {
+	size  int
+	step  int
+	seen  int
+	last  int
+	items []timed[T]
+}
+
+func (w *countWindower[T]) add(now time.Time, x T) []Window[T] {
+	w.items = append(w.items, timed[T]{at: now, x: x})
+	if len(w.items) > w.size {
+		w.items = w.items[1:]
+	}
+	w.seen++
+	if w.seen < w.size || (w.seen-w.size)%w.step != 0 {
+		return nil
+	}
+	w.last = w.seen
+	ret := []Window[T]{timedWindow(w.items)}
+	if w.step >= w.size {
+		w.items = nil
+	}
+	return ret
+}
+
+func (w *countWindower[T]) tick(time.Time) []Window[T] {
+	return nil
+}
+
+func (w *countWindower[T]) deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (w *countWindower[T]) flush() []Window[T] {
+	// The last window starts where the next full one would have, and is
+	// only emitted if it holds elements that were not in any window yet.
+	start := 0
+	if w.last > 0 {
+		start = w.last - w.size + w.step
+	}
+	if w.seen == w.last || w.seen <= start {
+		return nil
+	}
+	w.last = w.seen
+	return []Window[T]{timedWindow(w.items[len(w.items)-(w.seen-start):])}
+}
+
+// TumblingWindow groups elements into consecutive, non-overlapping windows
+// of size elements. The last window may hold fewer elements.
+func TumblingWindow[T any](size int) WindowSpec[T] {
+	return SlidingWindow[T](size, size)
+}
+
+// SlidingWindow groups elements into windows of size elements, starting a
+// new window every step elements. Windows overlap when step is smaller
+// than size, and elements are skipped when it is larger. Once the input
+// ends, if some elements were not part of any window yet, the window
+// starting at the next step is emitted with the elements it got so far.
+func SlidingWindow[T any](size int, step int) WindowSpec[T] {
+	size, step = max(size, 1), max(step, 1)
+	return WindowSpec[T]{func() windower[T] {
+		return &countWindower[T]{size: size, step: step}
+	}}
+}
+
+// ------------------------------------------------------------------------
+//  Time-Based Windows
+// ------------------------------------------------------------------------
+
+type timeWindower[T any] struct {
+	span  time.Duration
+	slide time.Duration
+	start time.Time
+	items []timed[T]
+}
+
+func (w *timeWindower[T]) add(now time.Time, x T) []Window[T] {
+	ret := w.tick(now)
+	if len(w.items) == 0 {
+		// The earliest window containing now starts at the first multiple
+		// of slide strictly after now - span.
+		w.start = now.Add(-w.span).Truncate(w.slide).Add(w.slide)
+	}
+	w.items = append(w.items, timed[T]{at: now, x: x})
+	return ret
+}
+
+func (w *timeWindower[T]) tick(now time.Time) []Window[T] {
+	var ret []Window[T]
+	for len(w.items) > 0 && !w.start.Add(w.span).After(now) {
+		ret = append(ret, w.emit()...)
+	}
+	return ret
+}
+
+// emit closes the window at w.start and moves on to the next one, dropping
+// the elements that no later window covers.
+func (w *timeWindower[T]) emit() []Window[T] {
+	var ret []Window[T]
+	end := w.start.Add(w.span)
+	items := make([]T, 0, len(w.items))
+	for _, e := range w.items {
+		if !e.at.Before(w.start) && e.at.Before(end) {
+			items = append(items, e.x)
+		}
+	}
+	if len(items) > 0 {
+		ret = append(ret, Window[T]{Start: w.start, End: end, Items: items})
+	}
+
+	w.start = w.start.Add(w.slide)
+	i := 0
+	for i < len(w.items) && w.items[i].at.Before(w.start) {
+		i++
+	}
+	w.items = w.items[i:]
+	if len(w.items) > 0 && w.items[0].at.Sub(w.start) >= w.span {
+		// Skip the empty windows in between.
+		w.start = w.items[0].at.Add(-w.span).Truncate(w.slide).Add(w.slide)
+	}
+	return ret
+}
+
+func (w *timeWindower[T]) deadline() (time.Time, bool) {
+	if len(w.items) == 0 {
+		return time.Time{}, false
+	}
+	return w.start.Add(w.span), true
+}
+
+func (w *timeWindower[T]) flush() []Window[T] {
+	var ret []Window[T]
+	for len(w.items) > 0 {
+		ret = append(ret, w.emit()...)
+	}
+	return ret
+}
+
+// TumblingTimeWindow groups elements into consecutive, non-overlapping
+// windows lasting span, aligned on multiples of span since the zero time.
+func TumblingTimeWindow[T any](span time.Duration) WindowSpec[T] {
+	return SlidingTimeWindow[T](span, span)
+}
+
+// SlidingTimeWindow groups elements into windows lasting span, starting a
+// new window every slide. Windows are aligned on multiples of slide since
+// the zero time, and windows without any element are not emitted.
+func SlidingTimeWindow[T any](span time.Duration, slide time.Duration) WindowSpec[T] {
+	span, slide = max(span, 1), max(slide, 1)
+	return WindowSpec[T]{func() windower[T] {
+		return &timeWindower[T]{span: span, slide: slide}
+	}}
+}
+
+type sessionWindower[T any] struct {
+	gap   time.Duration
+	items []timed[T]
+}
+
+func (w *sessionWindower[T]) add(now time.Time, x T) []Window[T] {
+	ret := w.tick(now)
+	w.items = append(w.items, timed[T]{at: now, x: x})
+	return ret
+}
+
+func (w *sessionWindower[T]) tick(now time.Time) []Window[T] {
+	if at, ok := w.deadline(); ok && !at.After(now) {
+		return w.flush()
+	}
+	return nil
+}
+
+func (w *sessionWindower[T]) deadline() (time.Time, bool) {
+	if len(w.items) == 0 {
+		return time.Time{}, false
+	}
+	return w.items[len(w.items)-1].at.Add(w.gap), true
+}
+
+func (w *sessionWindower[T]) flush() []Window[T] {
+	if len(w.items) == 0 {
+		return nil
+	}
+	ret := []Window[T]{timedWindow(w.items)}
+	w.items = nil
+	return ret
+}
+
+// SessionWindow groups elements into sessions that close once no element
+// has arrived for gap.
+func SessionWindow[T any](gap time.Duration) WindowSpec[T] {
+	return WindowSpec[T]{func() windower[T] {
+		return &sessionWindower[T]{gap: gap}
+	}}
+}
+
+// ------------------------------------------------------------------------
+//  Windowing Stages
+// ------------------------------------------------------------------------
+
+type windowStream[T any] struct {
+	s     Stream[T]
+	w     windower[T]
+	clock Clock
+	ready []Window[T]
+	done  bool
+}
+
+func (s *windowStream[T]) Next() (Window[T], error) {
+	for len(s.ready) == 0 {
+		if s.done {
+			return Window[T]{}, ErrStreamExhausted
+		}
+		x, err := s.s.Next()
+		if errors.Is(err, ErrStreamExhausted) {
+			s.done = true
+			s.ready = s.w.flush()
+			continue
+		}
+		if err != nil {
+			return Window[T]{}, err
+		}
+		s.ready = s.w.add(s.clock.Now(), x)
+	}
+	w := s.ready[0]
+	s.ready = s.ready[1:]
+	return w, nil
+}
+
+func (s *windowStream[T]) Close() error {
+	return StreamClose(s.s)
+}
+
+// StreamWindow returns a stream that groups the elements of s into the
+// windows described by spec, stamping every element with the time given
+// by clock when it is pulled. Since a stream is pulled on demand, a
+// time-based window can only close when the next element arrives or when
+// s is exhausted, at which point the remaining windows are flushed. Use
+// ChanWindow to have windows close on time. A nil clock means
+// SystemClock.
+//
+// Windows can be reduced to an aggregate with StreamMap:
+//
+//	sums := StreamMap(StreamWindow(s, TumblingWindow[int](10), nil), func(w Window[int]) int {
+//	    sum := 0
+//	    for _, x := range w.Items {
+//	        sum += x
+//	    }
+//	    return sum
+//	})
+func StreamWindow[T any](s Stream[T], spec WindowSpec[T], clock Clock) Stream[Window[T]] {
+	return &windowStream[T]{s: s, w: spec.newWindower(), clock: clockOrSystem(clock)}
+}
+
+// ChanWindow groups the elements received from ch into the windows
+// described by spec and sends them on the returned channel. Unlike
+// StreamWindow, time-based and session windows close as soon as clock
+// reaches their end, even if ch stays silent. When ch is closed, the
+// remaining windows are flushed and the returned channel is closed. When
+// ctx is done, the returned channel is closed and pending windows are
+// dropped. A nil clock means SystemClock.
+func ChanWindow[T any](ctx context.Context, ch <-chan T, spec WindowSpec[T], clock Clock) <-chan Window[T] {
+	clock = clockOrSystem(clock)
+	out := make(chan Window[T])
+	go func() {
+		defer close(out)
+		w := spec.newWindower()
+		send := func(ws []Window[T]) bool {
+			for _, x := range ws {
+				select {
+				case out <- x:
+				case <-ctx.Done():
+					return false
+				}
+			}
+			return true
+		}
+
+		timer := &deadlineTimer{clock: clock}
+		defer timer.release()
+		for {
+			wait := timer.reset(w.deadline())
+			select {
+			case x, ok := <-ch:
+				if !ok {
+					send(w.flush())
+					return
+				}
+				if !send(w.add(clock.Now(), x)) {
+					return
+				}
+			case now := <-wait:
+				timer.fired()
+				if !send(w.tick(now)) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,129 @@
+package vino_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	. "github.com/humbornjo/vino"
+	"github.com/stretchr/testify/assert"
+)
+
+// tickingStream yields xs, moving clock forward by the matching entry of
+// gaps before every element.
+type tickingStream struct {
+	clock *ManualClock
+	xs    []int
+	gaps  []time.Duration
+}
+
+func (s *tickingStream) Next() (int, error) {
+	if len(s.xs) == 0 {
+		return 0, ErrStreamExhausted
+	}
+	s.clock.Advance(s.gaps[0])
+	x := s.xs[0]
+	s.xs, s.gaps = s.xs[1:], s.gaps[1:]
+	return x, nil
+}
+
+func windowItems[T any](t *testing.T, s Stream[Window[T]]) [][]T {
+	ws, err := StreamCollect(s)
+	assert.NoError(t, err)
+	ret := make([][]T, len(ws))
+	for i, w := range ws {
+		ret[i] = w.Items
+	}
+	return ret
+}
+
+func TestStreamWindow_Count(t *testing.T) {
+	tests := []struct {
+		name string
+		spec WindowSpec[int]
+		want [][]int
+	}{
+		{"tumbling", TumblingWindow[int](2), [][]int{{0, 1}, {2, 3}, {4}}},
+		{"sliding", SlidingWindow[int](3, 1), [][]int{{0, 1, 2}, {1, 2, 3}, {2, 3, 4}}},
+		{"sliding with step", SlidingWindow[int](2, 2), [][]int{{0, 1}, {2, 3}, {4}}},
+		{"hopping", SlidingWindow[int](1, 2), [][]int{{0}, {2}, {4}}},
+		{"trailing", SlidingWindow[int](3, 2), [][]int{{0, 1, 2}, {2, 3, 4}}},
+		{"hopping trailing", SlidingWindow[int](2, 4), [][]int{{0, 1}, {4}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, windowItems(t, StreamWindow(rangeStream(5), tt.spec, nil)))
+		})
+	}
+
+	// The last partial window starts at a step boundary.
+	assert.Equal(t, [][]int{{0, 1, 2}, {2, 3}}, windowItems(t, StreamWindow(rangeStream(4), SlidingWindow[int](3, 2), nil)))
+	assert.Equal(t, [][]int{{0, 1, 2}, {2, 3, 4}, {4, 5}}, windowItems(t, StreamWindow(rangeStream(6), SlidingWindow[int](3, 2), nil)))
+	assert.Equal(t, [][]int{{0, 1}}, windowItems(t, StreamWindow(rangeStream(3), SlidingWindow[int](2, 4), nil)))
+}
+
+func TestStreamWindow_Time(t *testing.T) {
+	epoch := time.Unix(0, 0)
+	sec := time.Second
+	gaps := []time.Duration{0, sec, sec, 5 * sec, sec}
+
+	tests := []struct {
+		name string
+		spec WindowSpec[int]
+		want [][]int
+	}{
+		{"tumbling", TumblingTimeWindow[int](2 * sec), [][]int{{0}, {1, 2}, {3, 4}}},
+		{"sliding", SlidingTimeWindow[int](2*sec, sec), [][]int{{0}, {0, 1}, {1, 2}, {2}, {3}, {3, 4}, {4}}},
+		{"session", SessionWindow[int](2 * sec), [][]int{{0, 1, 2}, {3, 4}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := NewManualClock(epoch.Add(-sec / 2))
+			s := &tickingStream{clock: clock, xs: []int{0, 1, 2, 3, 4}, gaps: gaps}
+			assert.Equal(t, tt.want, windowItems(t, StreamWindow[int](s, tt.spec, clock)))
+		})
+	}
+
+	clock := NewManualClock(epoch)
+	s := &tickingStream{clock: clock, xs: []int{0, 1}, gaps: []time.Duration{0, 3 * sec}}
+	ws, err := StreamCollect(StreamWindow[int](s, TumblingTimeWindow[int](2*sec), clock))
+	assert.NoError(t, err)
+	assert.Equal(t, []Window[int]{
+		{Start: epoch, End: epoch.Add(2 * sec), Items: []int{0}},
+		{Start: epoch.Add(2 * sec), End: epoch.Add(4 * sec), Items: []int{1}},
+	}, ws)
+}
+
+func TestChanWindow(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	ch := make(chan int)
+	out := ChanWindow(context.Background(), ch, SessionWindow[int](time.Second), clock)
+
+	// Every element handled moves the deadline, and the timer along.
+	ch <- 1
+	clock.BlockUntil(1)
+	assert.Equal(t, []time.Time{time.Unix(1, 0)}, clock.Deadlines())
+	clock.Advance(time.Second / 2)
+	ch <- 2
+	assert.Eventually(t, func() bool {
+		return slices.Equal(clock.Deadlines(), []time.Time{time.Unix(1, 0).Add(time.Second / 2)})
+	}, time.Second, time.Millisecond)
+	clock.Advance(time.Second)
+	select {
+	case w := <-out:
+		assert.Equal(t, []int{1, 2}, w.Items)
+	case <-time.After(time.Second):
+		t.Fatal("session window did not close on time")
+	}
+
+	ch <- 3
+	close(ch)
+	w, ok := <-out
+	assert.True(t, ok)
+	assert.Equal(t, []int{3}, w.Items)
+	_, ok = <-out
+	assert.False(t, ok)
+}